@@ -1,6 +1,7 @@
 package gitingo
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/kasodeep/gitingo/commands"
@@ -8,23 +9,34 @@ import (
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add [path...]",
+	Use:   "add [-p] [path...]",
 	Short: "Add file contents to the index",
 	Long: `Add file contents to the index.
 			This command updates the index using the current content found in
-			the working tree, preparing the content for the next commit.`,
-	Args: cobra.MinimumNArgs(1),
+			the working tree, preparing the content for the next commit.
+			With -p, each changed hunk of the tracked files is shown and
+			staged only if accepted.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if patch, _ := cmd.Flags().GetBool("patch"); !patch && len(args) == 0 {
+			return fmt.Errorf("nothing specified, nothing added")
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cwd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
 
+		if patch, _ := cmd.Flags().GetBool("patch"); patch {
+			return commands.AddPatch(cwd, args, os.Stdin)
+		}
 		err = commands.Add(cwd, args)
 		return err
 	},
 }
 
 func init() {
+	addCmd.Flags().BoolP("patch", "p", false, "interactively choose hunks to stage")
 	rootCmd.AddCommand(addCmd)
 }
@@ -0,0 +1,54 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var catFileCmd = &cobra.Command{
+	Use:   "cat-file (-t | -s | -p | -e) <object>",
+	Short: "Provide content, type or size information for an object",
+	Long: `Provide content, type or size information for an object.
+			<object> is a full or abbreviated object id. -t prints the
+			object's type, -s its size in bytes and -p its content: trees
+			are listed one entry per line, a chunked file is printed whole,
+			and other objects as stored. -e prints nothing and only sets
+			the exit status: zero when the object exists, one otherwise.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		flags := cmd.Flags()
+		if exists, _ := flags.GetBool("exists"); exists {
+			if !commands.ObjectExists(cwd, args[0]) {
+				os.Exit(1)
+			}
+			return nil
+		}
+
+		mode := "pretty"
+		if t, _ := flags.GetBool("type"); t {
+			mode = "type"
+		} else if s, _ := flags.GetBool("size"); s {
+			mode = "size"
+		}
+		return commands.CatFile(cwd, args[0], mode)
+	},
+}
+
+func init() {
+	flags := catFileCmd.Flags()
+	flags.BoolP("type", "t", false, "show the object type")
+	flags.BoolP("size", "s", false, "show the object size")
+	flags.BoolP("pretty", "p", false, "pretty-print the object content")
+	flags.BoolP("exists", "e", false, "exit with zero status if the object exists")
+
+	catFileCmd.MarkFlagsMutuallyExclusive("type", "size", "pretty", "exists")
+	catFileCmd.MarkFlagsOneRequired("type", "size", "pretty", "exists")
+	rootCmd.AddCommand(catFileCmd)
+}
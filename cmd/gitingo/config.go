@@ -10,13 +10,14 @@ import (
 
 var name string
 var email string
+var chunkThreshold string
 
 var configCmd = &cobra.Command{
-	Use:   "config [--name | --email] <value>",
+	Use:   "config [--name | --email | --chunk-threshold] <value>",
 	Short: "Provides the config details for the commit",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if name == "" && email == "" {
-			return fmt.Errorf("at least one of --name or --email must be provided")
+		if name == "" && email == "" && chunkThreshold == "" {
+			return fmt.Errorf("at least one of --name, --email or --chunk-threshold must be provided")
 		}
 
 		cwd, err := os.Getwd()
@@ -24,7 +25,7 @@ var configCmd = &cobra.Command{
 			return err
 		}
 
-		return commands.Config(cwd, name, email)
+		return commands.Config(cwd, name, email, chunkThreshold)
 	},
 }
 
@@ -45,5 +46,12 @@ func init() {
 		"user email",
 	)
 
+	configCmd.Flags().StringVar(
+		&chunkThreshold,
+		"chunk-threshold",
+		"",
+		`store files at least this large (e.g. "8m") as chunk lists; "0" disables`,
+	)
+
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,30 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var countObjectsCmd = &cobra.Command{
+	Use:   "count-objects [-v]",
+	Short: "Count loose objects and their disk usage",
+	Long: `Count loose objects and their disk usage.
+			With -v, also report packed objects, pack sizes, loose objects
+			already present in a pack, and garbage files under objects/.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		return commands.CountObjects(cwd, verbose)
+	},
+}
+
+func init() {
+	countObjectsCmd.Flags().BoolP("verbose", "v", false, "report packs and garbage too")
+	rootCmd.AddCommand(countObjectsCmd)
+}
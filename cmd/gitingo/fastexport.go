@@ -0,0 +1,35 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var fastExportCmd = &cobra.Command{
+	Use:   "fast-export [--all | <rev>]",
+	Short: "Export history as a git fast-import stream",
+	Long: `Export history as a git fast-import stream.
+			Writes the commits reachable from <rev> (default HEAD), or from
+			every branch with --all, to stdout. Pipe the output into
+			"git fast-import" to rebuild the history in a git repository.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		all, _ := cmd.Flags().GetBool("all")
+		var rev string
+		if len(args) > 0 {
+			rev = args[0]
+		}
+		return commands.FastExport(cwd, rev, all)
+	},
+}
+
+func init() {
+	fastExportCmd.Flags().Bool("all", false, "export every branch")
+	rootCmd.AddCommand(fastExportCmd)
+}
@@ -0,0 +1,29 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Verify the connectivity and validity of the objects in the database",
+	Long: `Verify the connectivity and validity of the objects in the database.
+			Reports corrupt, missing and dangling objects, and exits non-zero
+			when anything is corrupt or missing.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return commands.Fsck(cwd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fsckCmd)
+}
@@ -0,0 +1,28 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Pack reachable loose objects",
+	Long: `Pack reachable loose objects.
+			Every loose object reachable from a branch or HEAD is written into
+			a single pack under objects/pack/ and the loose copy is removed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return commands.GC(cwd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+}
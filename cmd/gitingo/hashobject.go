@@ -0,0 +1,45 @@
+package gitingo
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var hashObjectCmd = &cobra.Command{
+	Use:   "hash-object [-w] [-t <type>] [--stdin] [file...]",
+	Short: "Compute object ID and optionally create an object from a file",
+	Long: `Compute object ID and optionally create an object from a file.
+			Prints the id each file would be stored under, one per line,
+			hashed as an object of type -t: blob (the default), tree or
+			commit. With --stdin standard input is hashed too, before any
+			files. With -w the objects are also written into the object
+			store; only -w needs a repository.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := cmd.Flags()
+		write, _ := flags.GetBool("write")
+		objType, _ := flags.GetString("type")
+		stdin, _ := flags.GetBool("stdin")
+
+		if !stdin && len(args) == 0 {
+			return errors.New("no input: pass file paths or --stdin")
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return commands.HashObject(cwd, args, objType, write, stdin)
+	},
+}
+
+func init() {
+	flags := hashObjectCmd.Flags()
+	flags.BoolP("write", "w", false, "write the object into the object store")
+	flags.StringP("type", "t", "blob", "object type")
+	flags.Bool("stdin", false, "read the object from standard input")
+
+	rootCmd.AddCommand(hashObjectCmd)
+}
@@ -0,0 +1,32 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var importGitCmd = &cobra.Command{
+	Use:   "import-git <path>",
+	Short: "Import the history of a git repository",
+	Long: `Import the history of a git repository.
+			Creates a new repository in the current directory from the git
+			repository at <path>: every branch and its history is rewritten
+			into gitingo objects, HEAD is restored, and the mapping between
+			git and gitingo ids is kept in .gitingo/git-map.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		format, _ := cmd.Flags().GetString("object-format")
+		return commands.ImportGit(cwd, args[0], format)
+	},
+}
+
+func init() {
+	importGitCmd.Flags().String("object-format", "sha256", "object hash algorithm (sha256 or sha1)")
+	rootCmd.AddCommand(importGitCmd)
+}
@@ -16,11 +16,19 @@ var initCmd = &cobra.Command{
 			return err
 		}
 
-		err = commands.Init(cwd)
+		// Left unset, the format follows the reference repository.
+		var format string
+		if cmd.Flags().Changed("object-format") {
+			format, _ = cmd.Flags().GetString("object-format")
+		}
+		reference, _ := cmd.Flags().GetString("reference")
+		err = commands.Init(cwd, format, reference)
 		return err
 	},
 }
 
 func init() {
+	initCmd.Flags().String("object-format", "sha256", "object hash algorithm (sha256 or sha1)")
+	initCmd.Flags().String("reference", "", "borrow objects from another repository via alternates")
 	rootCmd.AddCommand(initCmd)
 }
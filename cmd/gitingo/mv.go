@@ -0,0 +1,32 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv [-f] <source>... <destination>",
+	Short: "Move or rename a file or directory",
+	Long: `Move or rename a file or directory.
+			Renames the file on disk and its index entry together, so the
+			move is staged as a rename rather than a deletion plus an
+			untracked file. With several sources the destination must be a
+			directory. An existing destination is replaced only with -f.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		return commands.Mv(cwd, args[:len(args)-1], args[len(args)-1], force)
+	},
+}
+
+func init() {
+	mvCmd.Flags().BoolP("force", "f", false, "overwrite an existing destination")
+	rootCmd.AddCommand(mvCmd)
+}
@@ -0,0 +1,38 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [--expire=<duration>] [--dry-run]",
+	Short: "Remove unreachable loose objects",
+	Long: `Remove unreachable loose objects.
+			Deletes loose objects that no branch, HEAD, reflog entry or staged
+			file can reach, once they are older than --expire (default two
+			weeks). The grace period protects objects another command has
+			just written but not yet referenced.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		expireFlag, _ := cmd.Flags().GetString("expire")
+		expire, err := commands.ParseExpire(expireFlag)
+		if err != nil {
+			return err
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return commands.Prune(cwd, expire, dryRun)
+	},
+}
+
+func init() {
+	pruneCmd.Flags().String("expire", "2w", `only prune objects older than this ("now", "30d", "2w", "36h")`)
+	pruneCmd.Flags().BoolP("dry-run", "n", false, "list what would be pruned without removing it")
+	rootCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,36 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [--staged] [--worktree] [--source=<rev>] <pathspec>...",
+	Short: "Restore working tree files or staged entries",
+	Long: `Restore working tree files or staged entries.
+			By default the named files in the working tree are restored from
+			the index. --staged restores the index from HEAD instead; give
+			both flags to restore both. --source restores from another
+			commit or branch. HEAD and all other files are left untouched.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		staged, _ := cmd.Flags().GetBool("staged")
+		worktree, _ := cmd.Flags().GetBool("worktree")
+		source, _ := cmd.Flags().GetString("source")
+		return commands.Restore(cwd, args, source, staged, worktree)
+	},
+}
+
+func init() {
+	restoreCmd.Flags().BoolP("staged", "S", false, "restore the index")
+	restoreCmd.Flags().BoolP("worktree", "W", false, "restore the working tree (default)")
+	restoreCmd.Flags().StringP("source", "s", "", "restore from this commit or branch instead")
+	rootCmd.AddCommand(restoreCmd)
+}
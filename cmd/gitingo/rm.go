@@ -0,0 +1,35 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var rmCmd = &cobra.Command{
+	Use:   "rm [--cached] [-r] [-f] <pathspec>...",
+	Short: "Remove files from the working tree and from the index",
+	Long: `Remove files from the working tree and from the index.
+			With --cached the files are only unstaged and stay on disk.
+			Directories are removed only with -r. Files with staged or
+			unstaged modifications are refused unless -f is given.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		cached, _ := cmd.Flags().GetBool("cached")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		force, _ := cmd.Flags().GetBool("force")
+		return commands.Rm(cwd, args, cached, recursive, force)
+	},
+}
+
+func init() {
+	rmCmd.Flags().Bool("cached", false, "only remove from the index, keeping the working tree files")
+	rmCmd.Flags().BoolP("recursive", "r", false, "allow recursive removal of directories")
+	rmCmd.Flags().BoolP("force", "f", false, "override the modification checks")
+	rootCmd.AddCommand(rmCmd)
+}
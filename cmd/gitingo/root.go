@@ -1,8 +1,10 @@
 package gitingo
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/kasodeep/gitingo/repository"
 	"github.com/spf13/cobra"
 )
 
@@ -12,8 +14,20 @@ var rootCmd = &cobra.Command{
 	Long:  "gitingo is a simple, command-line reimplementation of Git internals in Go.",
 }
 
+// trace enables diagnostics on stderr, such as object cache hit rates.
+var trace bool
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if trace {
+		hits, misses := repository.CacheStats()
+		fmt.Fprintf(os.Stderr, "trace: object cache %d hits, %d misses\n", hits, misses)
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&trace, "trace", os.Getenv("GITINGO_TRACE") != "", "print object cache statistics to stderr (or set GITINGO_TRACE)")
+}
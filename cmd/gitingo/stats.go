@@ -0,0 +1,29 @@
+package gitingo
+
+import (
+	"os"
+
+	"github.com/kasodeep/gitingo/commands"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show repository statistics",
+	Long: `Show repository statistics.
+			Reports object totals for the reachable history, the largest
+			blobs, the deepest trees and the number of commits on each
+			branch.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return commands.Stats(cwd)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
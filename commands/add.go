@@ -12,16 +12,25 @@ func Add(base string, paths []string) error {
 		return err
 	}
 
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	idx, err := index.LoadIndex(repo)
 	if err != nil {
 		return err
 	}
 
 	if len(paths) == 1 && paths[0] == "." {
-		idx.AddFromPath(repo, repo.WorkDir, true)
+		err = idx.AddFromPath(repo, repo.WorkDir, true)
 	} else {
-		idx.AddFiles(repo, paths)
+		err = idx.AddFiles(repo, paths)
+	}
+	if err != nil {
+		return err
 	}
 
-	return idx.Write(repo)
+	return idx.WriteLocked(repo, lock)
 }
@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Interactive hunk staging
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// add -p walks the working-tree-vs-index diff of every modified file, one
+// hunk at a time, and asks whether to stage it. The staged blob is rebuilt
+// from the index version with only the accepted hunks applied, so the
+// working tree itself is never touched.
+
+// AddPatch interactively stages hunks of modified tracked files, limited to
+// pathspecs when any are given. Answers are read from in, one per line:
+//
+//	y  stage this hunk       n  do not stage this hunk
+//	s  split into smaller    q  quit; stage nothing further
+//
+// Hunks accepted before a q are still staged.
+func AddPatch(base string, pathspecs []string, in io.Reader) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	idx, err := index.LoadIndex(repo)
+	if err != nil {
+		return err
+	}
+	wdIdx, err := index.LoadWorkingDirIndex(repo)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, c := range DiffIndexes(idx, wdIdx) {
+		if c.Type == Modified && underPathspecs(c.Path, pathspecs) {
+			paths = append(paths, c.Path)
+		}
+	}
+	if len(paths) == 0 {
+		p.Info("no changes")
+		return nil
+	}
+	sort.Strings(paths)
+
+	ask := &hunkPrompt{in: bufio.NewReader(in)}
+	staged := 0
+	for _, path := range paths {
+		blob, ok, err := selectHunks(repo, idx.Entries[path], ask)
+		if err != nil {
+			return err
+		}
+		if ok {
+			hash, err := writePatched(repo, blob)
+			if err != nil {
+				return err
+			}
+			// No stat data: the file on disk no longer matches the entry.
+			idx.Entries[path] = index.IndexEntry{Mode: wdIdx.Entries[path].Mode, Hash: hash, Path: path}
+			staged++
+		}
+		if ask.quit {
+			break
+		}
+	}
+
+	if staged == 0 {
+		return nil
+	}
+	return idx.WriteLocked(repo, lock)
+}
+
+// selectHunks runs the prompt over one file's hunks and returns the content
+// to stage, or false when nothing was accepted.
+func selectHunks(repo *repository.Repository, entry index.IndexEntry, ask *hunkPrompt) ([]byte, bool, error) {
+	old, err := helper.ReadBlob(repo.Objects, entry.Hash)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", entry.Path, err)
+	}
+	cur, err := os.ReadFile(filepath.Join(repo.WorkDir, filepath.FromSlash(entry.Path)))
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.IndexByte(old, 0) >= 0 || bytes.IndexByte(cur, 0) >= 0 {
+		p.Warn(fmt.Sprintf("%s: binary file, skipped", entry.Path))
+		return nil, false, nil
+	}
+
+	oldLines, newLines := splitLines(string(old)), splitLines(string(cur))
+	p.Info(fmt.Sprintf("diff --git a/%s b/%s", entry.Path, entry.Path))
+
+	var picks []patchHunk
+	queue := toPatchHunks(computeHunks(oldLines, newLines, 3))
+	for len(queue) > 0 && !ask.quit {
+		h := queue[0]
+		queue = queue[1:]
+
+		switch ask.answer(h, len(queue)) {
+		case 'y':
+			h.accept = true
+			picks = append(picks, h)
+		case 's':
+			queue = append(splitHunk(h), queue...)
+		default:
+			picks = append(picks, h)
+		}
+	}
+
+	accepted := false
+	for _, h := range picks {
+		accepted = accepted || h.accept
+	}
+	if !accepted {
+		return nil, false, nil
+	}
+
+	// The result keeps the index's final newline unless an accepted hunk
+	// reaches the end of the file, in which case it takes the working tree's.
+	endsNL := bytes.HasSuffix(old, []byte("\n"))
+	last := picks[len(picks)-1]
+	if last.accept && last.oldStart-1+last.oldCount() == len(oldLines) {
+		endsNL = bytes.HasSuffix(cur, []byte("\n"))
+	}
+	return applyHunks(oldLines, picks, endsNL), true, nil
+}
+
+// writePatched stores patched content the way add would store the file.
+func writePatched(repo *repository.Repository, content []byte) (string, error) {
+	size := int64(len(content))
+	if repo.ChunkThreshold > 0 && size >= repo.ChunkThreshold {
+		return helper.WriteChunked(repo.Objects, repo.Objects.Format(), size, bytes.NewReader(content))
+	}
+	return repo.Objects.Write("blob", content)
+}
+
+// underPathspecs reports whether path is named by, or lies inside, one of
+// pathspecs. No pathspecs match everything.
+func underPathspecs(path string, pathspecs []string) bool {
+	if len(pathspecs) == 0 {
+		return true
+	}
+	for _, spec := range pathspecs {
+		spec = filepath.ToSlash(filepath.Clean(spec))
+		if spec == "." || path == spec || strings.HasPrefix(path, spec+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Hunks
+// ─────────────────────────────────────────────────────────────────────────────
+
+// patchHunk is a hunk under review. Accepted hunks contribute their '+'
+// lines to the staged content, rejected ones their '-' lines.
+type patchHunk struct {
+	oldStart, newStart int
+	lines              []diffLine
+	accept             bool
+}
+
+func (h patchHunk) oldCount() int {
+	n := 0
+	for _, l := range h.lines {
+		if l.op != '+' {
+			n++
+		}
+	}
+	return n
+}
+
+func (h patchHunk) newCount() int {
+	n := 0
+	for _, l := range h.lines {
+		if l.op != '-' {
+			n++
+		}
+	}
+	return n
+}
+
+func toPatchHunks(hunks []hunk) []patchHunk {
+	out := make([]patchHunk, len(hunks))
+	for i, h := range hunks {
+		out[i] = patchHunk{oldStart: h.oldStart, newStart: h.newStart, lines: h.lines}
+	}
+	return out
+}
+
+// splitHunk cuts h at every run of context lines between two changes.
+// Each run is shared out between its neighbours, so the pieces still
+// cover every line of h exactly once and can be applied independently.
+// A hunk with a single change region comes back unchanged.
+func splitHunk(h patchHunk) []patchHunk {
+	var pieces []patchHunk
+	cur := patchHunk{oldStart: h.oldStart, newStart: h.newStart}
+	seenChange := false
+
+	for i := 0; i < len(h.lines); {
+		if h.lines[i].op != ' ' {
+			seenChange = true
+			cur.lines = append(cur.lines, h.lines[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(h.lines) && h.lines[j].op == ' ' {
+			j++
+		}
+		if !seenChange || j == len(h.lines) {
+			cur.lines = append(cur.lines, h.lines[i:j]...)
+			i = j
+			continue
+		}
+
+		// Context between two changes: first half trails this piece,
+		// second half leads the next.
+		mid := i + (j-i+1)/2
+		cur.lines = append(cur.lines, h.lines[i:mid]...)
+		pieces = append(pieces, cur)
+		cur = patchHunk{
+			oldStart: cur.oldStart + cur.oldCount(),
+			newStart: cur.newStart + cur.newCount(),
+			lines:    append([]diffLine(nil), h.lines[mid:j]...),
+		}
+		i = j
+	}
+	return append(pieces, cur)
+}
+
+// applyHunks rebuilds the content to stage from the index version's
+// lines and the reviewed hunks, in order.
+func applyHunks(oldLines []string, hunks []patchHunk, endsNL bool) []byte {
+	var out []string
+	pos := 0 // next unconsumed old line, 0-based
+	for _, h := range hunks {
+		out = append(out, oldLines[pos:h.oldStart-1]...)
+		pos = h.oldStart - 1
+		for _, l := range h.lines {
+			switch {
+			case l.op == ' ':
+				out = append(out, l.text)
+				pos++
+			case l.op == '-':
+				if !h.accept {
+					out = append(out, l.text)
+				}
+				pos++
+			case l.op == '+' && h.accept:
+				out = append(out, l.text)
+			}
+		}
+	}
+	out = append(out, oldLines[pos:]...)
+
+	if len(out) == 0 {
+		return nil
+	}
+	content := strings.Join(out, "\n")
+	if endsNL {
+		content += "\n"
+	}
+	return []byte(content)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Prompt
+// ─────────────────────────────────────────────────────────────────────────────
+
+// hunkPrompt shows hunks and reads the answers. End of input counts as q.
+type hunkPrompt struct {
+	in   *bufio.Reader
+	quit bool
+}
+
+// answer prints h and returns 'y', 's' or 'n'; a q also sets quit. s is
+// only returned when h can actually be split.
+func (a *hunkPrompt) answer(h patchHunk, remaining int) byte {
+	p.Info(fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.oldStart, h.oldCount(), h.newStart, h.newCount()))
+	for _, l := range h.lines {
+		switch l.op {
+		case ' ':
+			p.Info(" " + l.text)
+		default:
+			p.Warn(string(l.op) + l.text)
+		}
+	}
+
+	canSplit := len(splitHunk(h)) > 1
+	choices := "y,n,q"
+	if canSplit {
+		choices = "y,n,s,q"
+	}
+	for {
+		fmt.Printf("Stage this hunk (%d more) [%s,?]? ", remaining, choices)
+		line, err := a.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Println()
+			a.quit = true
+			return 'n'
+		}
+
+		switch strings.TrimSpace(line) {
+		case "y":
+			return 'y'
+		case "n":
+			return 'n'
+		case "q":
+			a.quit = true
+			return 'n'
+		case "s":
+			if canSplit {
+				return 's'
+			}
+			p.Warn("sorry, cannot split this hunk")
+		default:
+			p.Info("y - stage this hunk\nn - do not stage this hunk\n" +
+				"s - split the current hunk into smaller hunks\nq - quit; do not stage this hunk or any of the remaining ones")
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+	"github.com/kasodeep/gitingo/tree"
+)
+
+// CatFile prints information about a single object.
+//
+//	type   — the object type (blob, tree, commit)
+//	size   — the content size in bytes
+//	pretty — the content; trees are decoded one entry per line
+func CatFile(base, hash, mode string) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	return catObject(repo, hash, mode, os.Stdout)
+}
+
+// ObjectExists reports whether hash, possibly abbreviated, names exactly
+// one stored object. Backs `cat-file -e`, which communicates only through
+// its exit status.
+func ObjectExists(base, hash string) bool {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return false
+	}
+	_, err = helper.ResolveHash(repo.Objects, hash)
+	return err == nil
+}
+
+// catObject writes the requested view of hash to w. The object is
+// streamed, so printing a large blob does not load it into memory.
+// A chunk list is shown as the blob it stands for.
+func catObject(repo *repository.Repository, hash, mode string, w io.Writer) error {
+	hash, err := helper.ResolveHash(repo.Objects, hash)
+	if err != nil {
+		return err
+	}
+	objType, size, r, err := repo.Objects.Open(hash)
+	if err != nil {
+		return fmt.Errorf("not a valid object name %s", hash)
+	}
+	if objType == helper.ChunksType {
+		r.Close()
+		if size, r, err = helper.OpenBlob(repo.Objects, hash); err != nil {
+			return err
+		}
+		objType = "blob"
+	}
+	defer r.Close()
+
+	switch mode {
+	case "type":
+		fmt.Fprintln(w, objType)
+	case "size":
+		fmt.Fprintln(w, size)
+	case "pretty":
+		if objType == "tree" {
+			content, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			return printTreeEntries(content, repo.Objects.Format().Size(), w)
+		}
+		_, err = io.Copy(w, r)
+		return err
+	default:
+		return fmt.Errorf("unknown cat-file mode %q", mode)
+	}
+	return nil
+}
+
+// printTreeEntries decodes a tree body and prints one
+// "<mode> <type> <hash>\t<name>" line per entry, as tree.ParseTree sees it.
+func printTreeEntries(content []byte, hashSize int, w io.Writer) error {
+	entries, err := tree.DecodeEntries(content, hashSize)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		fmt.Fprintf(w, "%06s %s %s\t%s\n", e.Mode, entryType(e), e.Hash, e.Name)
+	}
+	return nil
+}
+
+// entryType names the object type a tree entry points at.
+func entryType(e tree.Entry) string {
+	if e.IsDir() {
+		return "tree"
+	}
+	return "blob"
+}
@@ -25,7 +25,10 @@ func CommitCommand(base, msg string) error {
 		return err
 	}
 
-	newTreeHash := tree.WriteTree(repo.GitDir, tree.Create(idx))
+	newTreeHash, err := tree.WriteTree(repo, tree.Create(idx))
+	if err != nil {
+		return err
+	}
 
 	parentHash, err := repo.ReadHead()
 	if err != nil {
@@ -38,7 +41,10 @@ func CommitCommand(base, msg string) error {
 		return nil
 	}
 
-	commitHash := commit.WriteCommitObject(repo.GitDir, newTreeHash, parentHash, msg)
+	commitHash, err := commit.WriteCommitObject(repo, newTreeHash, parentHash, msg)
+	if err != nil {
+		return err
+	}
 	if err := repo.WriteHead([]byte(commitHash)); err != nil {
 		return err
 	}
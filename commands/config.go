@@ -2,11 +2,19 @@ package commands
 
 import "github.com/kasodeep/gitingo/repository"
 
-func Config(base, name, email string) error {
+func Config(base, name, email, chunkThreshold string) error {
 	repo, err := repository.GetRepository(base)
 	if err != nil {
 		return err
 	}
 
+	if chunkThreshold != "" {
+		if err := repository.SetChunkThreshold(repo.GitDir, chunkThreshold); err != nil {
+			return err
+		}
+		if name == "" && email == "" {
+			return nil
+		}
+	}
 	return repository.WriteConfig(repo.GitDir, name, email)
 }
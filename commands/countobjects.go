@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// CountObjects reports how many loose objects the repository holds and
+// the disk space they use. With verbose set it also covers packs, loose
+// objects a prune could drop because they are already packed, and
+// garbage files. Sizes are printed in KiB, as git does.
+func CountObjects(base string, verbose bool) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	c, err := helper.CountObjects(repo.GitDir)
+	if err != nil {
+		return err
+	}
+
+	if !verbose {
+		p.Info(fmt.Sprintf("%d objects, %d kilobytes", c.Count, kib(c.Size)))
+		return nil
+	}
+
+	p.Info(fmt.Sprintf("count: %d", c.Count))
+	p.Info(fmt.Sprintf("size: %d", kib(c.Size)))
+	p.Info(fmt.Sprintf("in-pack: %d", c.InPack))
+	p.Info(fmt.Sprintf("packs: %d", c.Packs))
+	p.Info(fmt.Sprintf("size-pack: %d", kib(c.SizePack)))
+	p.Info(fmt.Sprintf("prune-packable: %d", c.PrunePackable))
+	p.Info(fmt.Sprintf("garbage: %d", len(c.Garbage)))
+	p.Info(fmt.Sprintf("size-garbage: %d", kib(c.SizeGarbage)))
+	for _, path := range c.Garbage {
+		p.Warn("garbage found: " + path)
+	}
+	return nil
+}
+
+// kib rounds a byte count up to whole kibibytes.
+func kib(n int64) int64 { return (n + 1023) / 1024 }
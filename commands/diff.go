@@ -17,6 +17,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/kasodeep/gitingo/commit"
 	"github.com/kasodeep/gitingo/helper"
 	"github.com/kasodeep/gitingo/index"
 	"github.com/kasodeep/gitingo/repository"
@@ -49,7 +50,10 @@ func Diff(base string, args []string) error {
 		if err != nil {
 			return err
 		}
-		workingIdx := index.LoadWorkingDirIndex(repo)
+		workingIdx, err := index.LoadWorkingDirIndex(repo)
+		if err != nil {
+			return err
+		}
 		changes = DiffIndexes(stagedIdx, workingIdx)
 
 	case 2:
@@ -86,23 +90,22 @@ func Diff(base string, args []string) error {
 // This is the same path LoadCommitIndex takes for HEAD, but for any SHA.
 // We don't touch repo.HEAD at all — completely read-only.
 func indexFromCommit(repo *repository.Repository, commitSHA string) (*index.Index, error) {
-	// Step 1: read the raw commit object.
-	// helper.ReadObject strips the "<type> <size>\x00" header for us.
-	// What remains for a commit looks like:
-	//
-	//   tree <treehash>\n
-	//   parent <hash>\n        ← optional, absent on first commit
-	//   author ...\n
-	//   committer ...\n
-	//   \n
-	//   <commit message>
-	content, ok := helper.ReadObject(repo.GitDir, commitSHA)
-	if !ok {
+	// Step 0: expand an abbreviated hash such as the 7-char form log shows.
+	commitSHA, err := helper.ResolveHash(repo.Objects, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 1: parse the commit and take its root tree hash.
+	// commit.ParseCommit serves repeated lookups from repo.Cache, so
+	// diffing against the same commit twice only reads it once.
+	c, err := commit.ParseCommit(repo, commitSHA)
+	if err != nil {
 		return nil, fmt.Errorf("commit not found: %s", abbrev(commitSHA))
 	}
 
-	// Step 2: extract the tree hash from the first line.
-	treeHash := parseTreeLine(string(content))
+	// Step 2: a commit without a tree is corrupt.
+	treeHash := c.Tree
 	if treeHash == "" {
 		return nil, fmt.Errorf("no tree in commit %s", abbrev(commitSHA))
 	}
@@ -113,17 +116,6 @@ func indexFromCommit(repo *repository.Repository, commitSHA string) (*index.Inde
 	return buildIndexFromTree(repo, treeHash)
 }
 
-// parseTreeLine scans a raw commit body for the "tree <hash>" header line
-// and returns the hash. Returns "" if not found.
-func parseTreeLine(commitBody string) string {
-	for _, line := range strings.SplitN(commitBody, "\n", 10) {
-		if strings.HasPrefix(line, "tree ") {
-			return strings.TrimPrefix(line, "tree ")
-		}
-	}
-	return ""
-}
-
 // buildIndexFromTree calls the same tree.ParseTree + tree.TreeToIndex
 // pipeline that LoadCommitIndex already uses in status.go.
 //
@@ -180,7 +172,7 @@ func renderDiff(repo *repository.Repository, changes []Change, mode diffMode) er
 // KEY INSIGHT: which side lives on disk vs in the object store depends on the mode.
 //
 //	Mode 1 (worktree vs index):
-//	  FromHash → staged blob   → object store  ✓ safe to ReadObject
+//	  FromHash → staged blob   → object store  ✓ safe to read from the store
 //	  ToHash   → working tree  → object store  ✗ never written, read file directly
 //
 //	Mode 2 (commit vs commit):
@@ -228,8 +220,8 @@ func readBlobLines(repo *repository.Repository, hash, path string, s side, mode
 	}
 
 	// All other cases: the blob is in the object store.
-	raw, ok := helper.ReadObject(repo.GitDir, hash)
-	if !ok {
+	raw, err := helper.ReadBlob(repo.Objects, hash)
+	if err != nil {
 		return nil, fmt.Errorf("diff: cannot read blob %s", abbrev(hash))
 	}
 	return splitLines(string(raw)), nil
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kasodeep/gitingo/commit"
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+	"github.com/kasodeep/gitingo/tree"
+)
+
+// fastExporter writes a git fast-import stream. Every blob and commit is
+// emitted once and given a mark, which later commands refer back to.
+type fastExporter struct {
+	repo  *repository.Repository
+	w     *bufio.Writer
+	marks map[string]int // object hash → mark
+	next  int
+}
+
+// exportRef is one ref to export and the commit it points at.
+type exportRef struct {
+	name string // full ref name, e.g. "refs/heads/main"
+	hash string
+}
+
+// FastExport writes the history of the given rev — a branch, "HEAD" or a
+// (possibly abbreviated) commit id — to stdout as a git fast-import
+// stream. With all set, every branch is exported instead. An empty rev
+// means HEAD.
+//
+// The stream can be replayed with "git fast-import" to rebuild the
+// history in a git repository.
+func FastExport(base, rev string, all bool) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+
+	refs, err := exportRefs(repo, rev, all)
+	if err != nil {
+		return err
+	}
+
+	fe := &fastExporter{repo: repo, w: bufio.NewWriter(os.Stdout), marks: make(map[string]int)}
+	for _, ref := range refs {
+		if err := fe.exportHistory(ref); err != nil {
+			return err
+		}
+	}
+
+	// Commits shared between refs were emitted under whichever ref reached
+	// them first, so pin every ref to its own tip at the end.
+	for _, ref := range refs {
+		fmt.Fprintf(fe.w, "reset %s\nfrom :%d\n\n", ref.name, fe.marks[ref.hash])
+	}
+	return fe.w.Flush()
+}
+
+// exportRefs resolves the command line into the refs to export.
+func exportRefs(repo *repository.Repository, rev string, all bool) ([]exportRef, error) {
+	if all {
+		branches, err := repo.ListBranches()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(branches)
+
+		var refs []exportRef
+		for _, b := range branches {
+			hash, err := repo.ReadBranch(b)
+			if err != nil {
+				return nil, err
+			}
+			if hash != "" { // unborn branches have no history to export
+				refs = append(refs, exportRef{"refs/heads/" + b, hash})
+			}
+		}
+		return refs, nil
+	}
+
+	if rev == "" || rev == "HEAD" {
+		hash, err := repo.ReadHead()
+		if err != nil || hash == "" {
+			return nil, fmt.Errorf("no commit yet")
+		}
+		if repo.IsDetached {
+			return []exportRef{{"HEAD", hash}}, nil
+		}
+		return []exportRef{{"refs/heads/" + repo.CurrBranch, hash}}, nil
+	}
+
+	if repo.IsBranchExists(rev) {
+		hash, err := repo.ReadBranch(rev)
+		if err != nil || hash == "" {
+			return nil, fmt.Errorf("branch %s has no commits", rev)
+		}
+		return []exportRef{{"refs/heads/" + rev, hash}}, nil
+	}
+
+	// A bare commit id has no ref of its own; it is exported as a
+	// detached HEAD.
+	hash, err := helper.ResolveHash(repo.Objects, rev)
+	if err != nil {
+		return nil, err
+	}
+	if err := helper.Verify(repo.Objects, hash, "commit"); err != nil {
+		return nil, err
+	}
+	return []exportRef{{"HEAD", hash}}, nil
+}
+
+// exportHistory emits every not-yet-exported commit reachable from ref,
+// parents before children.
+func (fe *fastExporter) exportHistory(ref exportRef) error {
+	stack := []string{ref.hash}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		if _, done := fe.marks[hash]; done {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		c, err := commit.ParseCommit(fe.repo, hash)
+		if err != nil {
+			return err
+		}
+		var pending []string
+		for _, parent := range c.Parents {
+			if _, done := fe.marks[parent]; !done {
+				pending = append(pending, parent)
+			}
+		}
+		if len(pending) > 0 {
+			stack = append(stack, pending...)
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+		if err := fe.exportCommit(ref.name, hash, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportCommit emits the blobs new in c, then c itself as a diff against
+// its first parent.
+func (fe *fastExporter) exportCommit(refName, hash string, c *commit.Commit) error {
+	files, err := fe.flatten(c.Tree)
+	if err != nil {
+		return err
+	}
+	var parentFiles map[string]index.IndexEntry
+	if len(c.Parents) > 0 {
+		if parentFiles, err = fe.flatten(commit.ReadTreeHash(fe.repo, c.Parents[0])); err != nil {
+			return err
+		}
+	}
+
+	var changed, deleted []string
+	for path, e := range files {
+		if old, ok := parentFiles[path]; !ok || old.Hash != e.Hash || old.Mode != e.Mode {
+			changed = append(changed, path)
+		}
+	}
+	for path := range parentFiles {
+		if _, ok := files[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+
+	for _, path := range changed {
+		if err := fe.exportBlob(files[path].Hash); err != nil {
+			return err
+		}
+	}
+
+	fe.next++
+	fe.marks[hash] = fe.next
+
+	committer, committerEmail, committed, committerTZ :=
+		c.Committer, c.CommitterEmail, c.CommitTimestamp, c.CommitterTimezone
+	if committed == 0 {
+		committer, committerEmail, committed, committerTZ = c.Author, c.Email, c.Timestamp, c.Timezone
+	}
+
+	// Without a "from", fast-import would build on the ref's current tip;
+	// a reset makes a root commit start from nothing.
+	if len(c.Parents) == 0 {
+		fmt.Fprintf(fe.w, "reset %s\n", refName)
+	}
+	fmt.Fprintf(fe.w, "commit %s\nmark :%d\n", refName, fe.next)
+	fmt.Fprintf(fe.w, "author %s\n", fastIdent(c.Author, c.Email, c.Timestamp, c.Timezone))
+	fmt.Fprintf(fe.w, "committer %s\n", fastIdent(committer, committerEmail, committed, committerTZ))
+	msg := c.Msg + "\n"
+	fmt.Fprintf(fe.w, "data %d\n%s", len(msg), msg)
+	for i, parent := range c.Parents {
+		if i == 0 {
+			fmt.Fprintf(fe.w, "from :%d\n", fe.marks[parent])
+		} else {
+			fmt.Fprintf(fe.w, "merge :%d\n", fe.marks[parent])
+		}
+	}
+	for _, path := range deleted {
+		fmt.Fprintf(fe.w, "D %s\n", fastPath(path))
+	}
+	for _, path := range changed {
+		e := files[path]
+		fmt.Fprintf(fe.w, "M %s :%d %s\n", e.Mode, fe.marks[e.Hash], fastPath(path))
+	}
+	fmt.Fprintln(fe.w)
+	return nil
+}
+
+// exportBlob emits a blob the first time it is seen, streaming its content.
+func (fe *fastExporter) exportBlob(hash string) error {
+	if _, done := fe.marks[hash]; done {
+		return nil
+	}
+	size, r, err := helper.OpenBlob(fe.repo.Objects, hash)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fe.next++
+	fe.marks[hash] = fe.next
+	fmt.Fprintf(fe.w, "blob\nmark :%d\ndata %d\n", fe.next, size)
+	if _, err := io.CopyN(fe.w, r, size); err != nil {
+		return fmt.Errorf("blob %s: %w", abbrev(hash), err)
+	}
+	fmt.Fprintln(fe.w)
+	return nil
+}
+
+// flatten returns every file under a tree, keyed by slash-separated path.
+func (fe *fastExporter) flatten(treeHash string) (map[string]index.IndexEntry, error) {
+	root, err := tree.ParseTree(fe.repo, treeHash, "")
+	if err != nil {
+		return nil, err
+	}
+	idx := index.NewIndex()
+	tree.TreeToIndex(idx, root, "")
+
+	files := make(map[string]index.IndexEntry, len(idx.Entries))
+	for path, e := range idx.Entries {
+		files[filepath.ToSlash(path)] = e
+	}
+	return files, nil
+}
+
+// fastIdent formats an identity line. Commits written before timezones
+// were recorded are taken to be in UTC.
+func fastIdent(name, email string, ts int64, tz string) string {
+	if tz == "" {
+		tz = "+0000"
+	}
+	if name == "" {
+		return fmt.Sprintf("<%s> %d %s", email, ts, tz)
+	}
+	return fmt.Sprintf("%s <%s> %d %s", name, email, ts, tz)
+}
+
+// fastPath quotes a path the way fast-import expects when it would
+// otherwise be ambiguous or unreadable: when it starts with a quote or
+// holds a quote, backslash, control or non-ASCII byte.
+func fastPath(path string) string {
+	if !strings.HasPrefix(path, `"`) && strings.IndexFunc(path, needsCQuote) < 0 {
+		return path
+	}
+	return cQuote(path)
+}
+
+func needsCQuote(r rune) bool {
+	return r == '"' || r == '\\' || r < 0x20 || r >= 0x7f
+}
+
+// cQuote quotes s in C style, the only quoting git understands: the usual
+// backslash escapes, and three-digit octal for every other control or
+// non-ASCII byte. Unlike strconv.Quote it works byte by byte, so invalid
+// UTF-8 survives unchanged.
+func cQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,280 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+	"github.com/kasodeep/gitingo/tree"
+)
+
+// fsckLink is one reference from an object (or ref) to another object.
+type fsckLink struct {
+	from, to string
+	wantType string
+}
+
+// fsckReport accumulates everything fsck finds before it is printed.
+type fsckReport struct {
+	types    map[string]string // every readable object → its type
+	broken   map[string]bool   // objects present but unreadable or mis-hashed
+	links    []fsckLink
+	corrupt  []string
+	missing  []string
+	dangling []string
+}
+
+// Fsck verifies the integrity of the object store and refs.
+//
+// Every loose and packed object is read and re-hashed, trees and commits
+// are parsed, and every reference — tree entries, commit trees and parents,
+// branches and HEAD — is checked against the store. Unreachable objects
+// that nothing else points at are reported as dangling.
+//
+// Returns an error when any corrupt or missing object was found.
+func Fsck(base string) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+
+	r := &fsckReport{types: make(map[string]string), broken: make(map[string]bool)}
+	if err := r.checkPacks(repo); err != nil {
+		return err
+	}
+	if err := r.checkObjects(repo); err != nil {
+		return err
+	}
+	if err := r.checkRefs(repo); err != nil {
+		return err
+	}
+	r.checkLinks(repo)
+	r.findDangling()
+
+	r.print()
+	if n := len(r.corrupt) + len(r.missing); n > 0 {
+		return fmt.Errorf("fsck: %d problem(s) found", n)
+	}
+	return nil
+}
+
+// checkPacks verifies pack checksums and per-entry CRCs.
+func (r *fsckReport) checkPacks(repo *repository.Repository) error {
+	problems, err := helper.VerifyPacks(repo.GitDir)
+	if err != nil {
+		return err
+	}
+	for _, prob := range problems {
+		r.corrupt = append(r.corrupt, prob.Error())
+	}
+	return nil
+}
+
+// checkObjects reads every stored object, re-hashes it and records the
+// links it makes to other objects.
+func (r *fsckReport) checkObjects(repo *repository.Repository) error {
+	return repo.Objects.Iterate(func(hash string) error {
+		objType, content, err := repo.Objects.Read(hash)
+		if err != nil {
+			r.broken[hash] = true
+			r.corrupt = append(r.corrupt, fmt.Sprintf("object %s: %v", hash, err))
+			return nil
+		}
+		if _, sum := helper.PrepareObject(repo.Objects.Format(), objType, content); sum != hash {
+			r.broken[hash] = true
+			r.corrupt = append(r.corrupt, fmt.Sprintf("%s %s: hash mismatch (content hashes to %s)", objType, hash, sum))
+			return nil
+		}
+		r.types[hash] = objType
+
+		switch objType {
+		case "tree":
+			entries, err := tree.DecodeEntries(content, repo.Objects.Format().Size())
+			if err != nil {
+				r.corrupt = append(r.corrupt, fmt.Sprintf("tree %s: %v", hash, err))
+				return nil
+			}
+			for _, e := range entries {
+				r.links = append(r.links, fsckLink{from: hash, to: e.Hash, wantType: entryType(e)})
+			}
+
+		case "commit":
+			treeHash, parents := commitLinks(content)
+			if treeHash == "" {
+				r.corrupt = append(r.corrupt, fmt.Sprintf("commit %s: no tree header", hash))
+				return nil
+			}
+			r.links = append(r.links, fsckLink{from: hash, to: treeHash, wantType: "tree"})
+			for _, parent := range parents {
+				r.links = append(r.links, fsckLink{from: hash, to: parent, wantType: "commit"})
+			}
+
+		case helper.ChunksType:
+			chunks, err := helper.ParseChunks(content)
+			if err != nil {
+				r.corrupt = append(r.corrupt, fmt.Sprintf("chunk list %s: %v", hash, err))
+				return nil
+			}
+			for _, c := range chunks {
+				r.links = append(r.links, fsckLink{from: hash, to: c.Hash, wantType: "blob"})
+			}
+		}
+		return nil
+	})
+}
+
+// checkRefs records every branch, HEAD and reflog entry as links to
+// commits, and every staged index entry as a link to its blob. Reflogs are
+// roots just as they are for prune, so both agree on what is reachable.
+func (r *fsckReport) checkRefs(repo *repository.Repository) error {
+	branches, err := repo.ListBranches()
+	if err != nil {
+		return err
+	}
+	for _, b := range branches {
+		hash, err := repo.ReadBranch(b)
+		if err != nil {
+			return err
+		}
+		if hash != "" {
+			r.links = append(r.links, fsckLink{from: "refs/heads/" + b, to: hash, wantType: "commit"})
+		}
+	}
+
+	head, err := repo.ReadHead()
+	if err != nil {
+		return err
+	}
+	if head != "" {
+		r.links = append(r.links, fsckLink{from: "HEAD", to: head, wantType: "commit"})
+	}
+
+	logged, err := repo.RefLogHashes()
+	if err != nil {
+		return err
+	}
+	for _, hash := range logged {
+		r.links = append(r.links, fsckLink{from: "reflog", to: hash, wantType: "commit"})
+	}
+
+	idx, err := index.LoadIndex(repo)
+	if err != nil {
+		return err
+	}
+	for path, e := range idx.Entries {
+		r.links = append(r.links, fsckLink{from: "index:" + path, to: e.Hash, wantType: "blob"})
+	}
+	return nil
+}
+
+// checkLinks flags links to absent objects, or to objects of the wrong type.
+// Objects borrowed through alternates count as present; the repository that
+// owns them is responsible for checking what they link to.
+func (r *fsckReport) checkLinks(repo *repository.Repository) {
+	reported := make(map[string]bool)
+	for _, l := range r.links {
+		got, ok := r.types[l.to]
+		if !ok && !r.broken[l.to] {
+			got, ok = borrowedType(repo, l.to)
+		}
+		switch {
+		case !ok && !r.broken[l.to] && !reported[l.to]:
+			reported[l.to] = true
+			r.missing = append(r.missing, fmt.Sprintf("missing %s %s (referenced by %s)", l.wantType, l.to, l.from))
+		case ok && !r.typeMatches(l, got):
+			r.corrupt = append(r.corrupt, fmt.Sprintf("%s: %s is a %s, expected %s", l.from, l.to, got, l.wantType))
+		}
+	}
+}
+
+// typeMatches reports whether an object of type got satisfies link l.
+// Trees and the index may point at a chunk list wherever they would point
+// at a blob, but the entries of a chunk list must be real blobs.
+func (r *fsckReport) typeMatches(l fsckLink, got string) bool {
+	if got == l.wantType {
+		return true
+	}
+	return got == helper.ChunksType && l.wantType == "blob" && r.types[l.from] != helper.ChunksType
+}
+
+// borrowedType returns the type of an object found only in an alternate.
+func borrowedType(repo *repository.Repository, hash string) (string, bool) {
+	if !repo.Objects.Has(hash) {
+		return "", false
+	}
+	objType, _, rc, err := repo.Objects.Open(hash)
+	if err != nil {
+		return "", false
+	}
+	rc.Close()
+	return objType, true
+}
+
+// findDangling marks every object reachable from the refs, then reports
+// unreachable objects that no other object points at.
+func (r *fsckReport) findDangling() {
+	edges := make(map[string][]string)
+	referenced := make(map[string]bool)
+	var roots []string
+	for _, l := range r.links {
+		if _, isObject := r.types[l.from]; isObject {
+			edges[l.from] = append(edges[l.from], l.to)
+			referenced[l.to] = true
+		} else {
+			roots = append(roots, l.to)
+		}
+	}
+
+	reachable := make(map[string]bool)
+	for stack := roots; len(stack) > 0; {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reachable[h] {
+			continue
+		}
+		reachable[h] = true
+		stack = append(stack, edges[h]...)
+	}
+
+	hashes := make([]string, 0, len(r.types))
+	for h := range r.types {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+	for _, h := range hashes {
+		if !reachable[h] && !referenced[h] {
+			r.dangling = append(r.dangling, fmt.Sprintf("dangling %s %s", r.types[h], h))
+		}
+	}
+}
+
+func (r *fsckReport) print() {
+	for _, msg := range r.corrupt {
+		p.Error("corrupt: " + msg)
+	}
+	for _, msg := range r.missing {
+		p.Error(msg)
+	}
+	for _, msg := range r.dangling {
+		p.Warn(msg)
+	}
+	if len(r.corrupt)+len(r.missing) == 0 {
+		p.Success(fmt.Sprintf("checked %d objects, no problems found", len(r.types)))
+	}
+}
+
+// commitLinks extracts the tree and parent hashes from a raw commit body.
+func commitLinks(content []byte) (treeHash string, parents []string) {
+	headers, _, _ := strings.Cut(string(content), "\n\n")
+	for _, line := range strings.Split(headers, "\n") {
+		if v, ok := strings.CutPrefix(line, "tree "); ok {
+			treeHash = v
+		} else if v, ok := strings.CutPrefix(line, "parent "); ok {
+			parents = append(parents, v)
+		}
+	}
+	return treeHash, parents
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// GC packs every reachable or staged loose object into a new pack and then
+// removes the loose copies. Unreachable loose objects are left untouched.
+func GC(base string) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+
+	tips, err := refTips(repo)
+	if err != nil {
+		return err
+	}
+	reachable, err := walkReachable(repo, tips)
+	if err != nil {
+		return fmt.Errorf("gc: %w", err)
+	}
+
+	// Staged files are live too; a staged chunk list brings its chunks.
+	seen := make(map[string]bool, len(reachable))
+	for _, o := range reachable {
+		seen[o.Hash] = true
+	}
+	staged, err := indexObjects(repo, seen)
+	if err != nil {
+		return fmt.Errorf("gc: %w", err)
+	}
+	reachable = append(reachable, staged...)
+
+	loose, err := helper.LooseObjects(repo.GitDir)
+	if err != nil {
+		return err
+	}
+	isLoose := make(map[string]bool, len(loose))
+	for _, h := range loose {
+		isLoose[h] = true
+	}
+
+	var toPack []helper.PackObject
+	for _, o := range reachable {
+		if isLoose[o.Hash] {
+			toPack = append(toPack, helper.PackObject{Hash: o.Hash, Path: o.Path})
+		}
+	}
+	if len(toPack) == 0 {
+		p.Info("nothing to pack")
+		return nil
+	}
+
+	name, err := helper.WritePack(repo.GitDir, toPack)
+	if err != nil {
+		return fmt.Errorf("gc: %w", err)
+	}
+	for _, o := range toPack {
+		if err := helper.RemoveLoose(repo.GitDir, o.Hash); err != nil {
+			return fmt.Errorf("gc: %w", err)
+		}
+	}
+
+	p.Success(fmt.Sprintf("packed %d objects into pack-%s", len(toPack), abbrev(name)))
+	return nil
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// objectTypes are the object types hash-object accepts for -t.
+var objectTypes = map[string]bool{"blob": true, "tree": true, "commit": true}
+
+// HashObject prints the object id of each input and, when write is set,
+// stores it in the object store. Inputs are read from stdin (if requested)
+// first, then from each file, relative to base.
+//
+// A repository is only required when writing. Without one, ids are
+// computed in the default object format.
+func HashObject(base string, files []string, objType string, write, stdin bool) error {
+	if !objectTypes[objType] {
+		return fmt.Errorf("invalid object type %q", objType)
+	}
+
+	repo, err := repository.GetRepository(base)
+	if err != nil && write {
+		return err
+	}
+	format := helper.DefaultObjectFormat
+	if repo != nil {
+		format = repo.Objects.Format()
+	}
+
+	hashOne := func(size int64, r io.Reader) error {
+		var hash string
+		var err error
+		if write {
+			hash, err = repo.Objects.WriteStream(objType, size, r)
+		} else {
+			hash, err = helper.HashStream(format, objType, size, r)
+		}
+		if err != nil {
+			return err
+		}
+		p.Info(hash)
+		return nil
+	}
+
+	// The header needs the size up front, so stdin is the one input that
+	// has to be buffered.
+	if stdin {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if err := hashOne(int64(len(content)), bytes.NewReader(content)); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(base, f)
+		}
+		if err := hashFile(f, hashOne); err != nil {
+			return fmt.Errorf("cannot read %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+// hashFile streams one file into hashOne.
+func hashFile(path string, hashOne func(int64, io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return hashOne(info.Size(), f)
+}
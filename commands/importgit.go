@@ -0,0 +1,395 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kasodeep/gitingo/commit"
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+	"github.com/kasodeep/gitingo/tree"
+)
+
+// gitMapFile records, one "<git-id> <gitingo-id>" pair per line, which
+// gitingo object each imported git object became.
+const gitMapFile = "git-map"
+
+// gitImporter rewrites objects from a git store into a gitingo repository,
+// remembering every id it has already translated.
+type gitImporter struct {
+	src  *helper.GitStore
+	repo *repository.Repository
+	ids  map[string]string // git id → gitingo id
+
+	commits, trees, blobs int
+	submodules            int // gitlink entries dropped from trees
+}
+
+// ImportGit creates a new gitingo repository at base holding the history
+// of the git repository at src: every commit reachable from a branch or
+// HEAD is rewritten, with its trees and blobs, into objectFormat. Branches
+// and HEAD are recreated, and the id mapping is kept in .gitingo/git-map.
+//
+// src may be a work tree containing .git or a bare repository. Tags and
+// submodule entries have no gitingo equivalent and are skipped.
+func ImportGit(base, src, objectFormat string) error {
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(base, src)
+	}
+	gitDir := src
+	if helper.IsDirectory(filepath.Join(src, ".git")) {
+		gitDir = filepath.Join(src, ".git")
+	}
+
+	store, err := helper.OpenGitStore(gitDir)
+	if err != nil {
+		return err
+	}
+	branches, head, err := readGitRefs(gitDir)
+	if err != nil {
+		return err
+	}
+
+	format, err := helper.ParseObjectFormat(objectFormat)
+	if err != nil {
+		return err
+	}
+	repo := repository.NewRepository(base, format)
+	if err := repo.Create(); err != nil {
+		return err
+	}
+
+	imp := &gitImporter{src: store, repo: repo, ids: make(map[string]string)}
+
+	names := make([]string, 0, len(branches))
+	for name := range branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hash, err := imp.commit(branches[name])
+		if err != nil {
+			return fmt.Errorf("import branch %s: %w", name, err)
+		}
+		if err := repo.WriteBranch(name, hash); err != nil {
+			return err
+		}
+	}
+
+	if err := imp.restoreHead(head, branches); err != nil {
+		return err
+	}
+	if err := imp.writeMap(); err != nil {
+		return err
+	}
+	if err := imp.checkout(); err != nil {
+		return err
+	}
+
+	p.Success(fmt.Sprintf("imported %d commits, %d trees and %d blobs on %d branches",
+		imp.commits, imp.trees, imp.blobs, len(branches)))
+	if imp.submodules > 0 {
+		p.Warn(fmt.Sprintf("skipped %d submodule entries", imp.submodules))
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Object translation
+// ─────────────────────────────────────────────────────────────────────────────
+
+// commit translates tip and all of its ancestors, parents first. History
+// can be far deeper than the stack should go, so the walk is iterative.
+func (imp *gitImporter) commit(tip string) (string, error) {
+	stack := []string{tip}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		if _, done := imp.ids[hash]; done {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		typ, content, err := imp.src.Read(hash)
+		if err != nil {
+			return "", err
+		}
+		if typ != "commit" {
+			return "", fmt.Errorf("object %s is a %s, not a commit", hash, typ)
+		}
+
+		var pending []string
+		for _, parent := range gitCommitParents(content) {
+			if _, done := imp.ids[parent]; !done {
+				pending = append(pending, parent)
+			}
+		}
+		if len(pending) > 0 {
+			stack = append(stack, pending...)
+			continue
+		}
+
+		stack = stack[:len(stack)-1]
+		if err := imp.rewriteCommit(hash, content); err != nil {
+			return "", err
+		}
+	}
+	return imp.ids[tip], nil
+}
+
+// rewriteCommit points a commit's tree and parent headers at their gitingo
+// ids. Signatures cannot survive the rewrite, so gpgsig and mergetag
+// headers (and their continuation lines) are dropped; everything else,
+// including the message, is kept byte for byte.
+func (imp *gitImporter) rewriteCommit(hash string, content []byte) error {
+	headers, msg, _ := bytes.Cut(content, []byte("\n\n"))
+
+	var buf bytes.Buffer
+	dropping := false
+	for _, line := range strings.Split(string(headers), "\n") {
+		if strings.HasPrefix(line, " ") {
+			if !dropping {
+				buf.WriteString(line + "\n")
+			}
+			continue
+		}
+		dropping = false
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			treeHash, err := imp.tree(value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&buf, "tree %s\n", treeHash)
+		case "parent":
+			fmt.Fprintf(&buf, "parent %s\n", imp.ids[value])
+		case "gpgsig", "gpgsig-sha256", "mergetag":
+			dropping = true
+		default:
+			buf.WriteString(line + "\n")
+		}
+	}
+	buf.WriteString("\n")
+	buf.Write(msg)
+
+	newHash, err := imp.repo.Objects.Write("commit", buf.Bytes())
+	if err != nil {
+		return err
+	}
+	imp.ids[hash] = newHash
+	imp.commits++
+	return nil
+}
+
+// tree translates a git tree and everything under it.
+func (imp *gitImporter) tree(hash string) (string, error) {
+	if id, ok := imp.ids[hash]; ok {
+		return id, nil
+	}
+	typ, content, err := imp.src.Read(hash)
+	if err != nil {
+		return "", err
+	}
+	if typ != "tree" {
+		return "", fmt.Errorf("object %s is a %s, not a tree", hash, typ)
+	}
+	entries, err := tree.DecodeEntries(content, imp.src.Format().Size())
+	if err != nil {
+		return "", fmt.Errorf("tree %s: %w", hash, err)
+	}
+
+	out := entries[:0]
+	for _, e := range entries {
+		switch {
+		case e.IsDir():
+			e.Hash, err = imp.tree(e.Hash)
+		case e.Mode == "160000":
+			imp.submodules++
+			continue
+		default:
+			e.Mode = normaliseGitMode(e.Mode)
+			e.Hash, err = imp.blob(e.Hash)
+		}
+		if err != nil {
+			return "", err
+		}
+		out = append(out, e)
+	}
+
+	body, err := tree.EncodeEntries(imp.repo.Objects.Format(), out)
+	if err != nil {
+		return "", err
+	}
+	newHash, err := imp.repo.Objects.Write("tree", body)
+	if err != nil {
+		return "", err
+	}
+	imp.ids[hash] = newHash
+	imp.trees++
+	return newHash, nil
+}
+
+// blob copies a git blob; the content is unchanged, only its id differs.
+func (imp *gitImporter) blob(hash string) (string, error) {
+	if id, ok := imp.ids[hash]; ok {
+		return id, nil
+	}
+	typ, content, err := imp.src.Read(hash)
+	if err != nil {
+		return "", err
+	}
+	if typ != "blob" {
+		return "", fmt.Errorf("object %s is a %s, not a blob", hash, typ)
+	}
+	newHash, err := imp.repo.Objects.Write("blob", content)
+	if err != nil {
+		return "", err
+	}
+	imp.ids[hash] = newHash
+	imp.blobs++
+	return newHash, nil
+}
+
+// gitCommitParents returns the parent ids listed in a commit's headers.
+func gitCommitParents(content []byte) []string {
+	headers, _, _ := bytes.Cut(content, []byte("\n\n"))
+	var parents []string
+	for _, line := range strings.Split(string(headers), "\n") {
+		if rest, ok := strings.CutPrefix(line, "parent "); ok {
+			parents = append(parents, rest)
+		}
+	}
+	return parents
+}
+
+// normaliseGitMode maps the legacy group-writable modes very old git
+// versions recorded (e.g. 100664) onto the two regular-file modes.
+func normaliseGitMode(mode string) string {
+	if !strings.HasPrefix(mode, "100") || mode == "100644" || mode == "100755" {
+		return mode
+	}
+	if strings.ContainsAny(mode[3:], "1357") {
+		return "100755"
+	}
+	return "100644"
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Refs, HEAD and the working tree
+// ─────────────────────────────────────────────────────────────────────────────
+
+// readGitRefs returns every branch in a git repository, from packed-refs
+// and loose refs (which win), along with the raw content of HEAD.
+func readGitRefs(gitDir string) (map[string]string, string, error) {
+	branches := make(map[string]string)
+
+	if f, err := os.Open(filepath.Join(gitDir, "packed-refs")); err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			hash, ref, ok := strings.Cut(sc.Text(), " ")
+			if !ok || strings.HasPrefix(hash, "#") || strings.HasPrefix(hash, "^") {
+				continue
+			}
+			if name, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+				branches[name] = hash
+			}
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	heads := filepath.Join(gitDir, "refs", "heads")
+	err := filepath.WalkDir(heads, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(heads, path)
+		branches[filepath.ToSlash(name)] = strings.TrimSpace(string(data))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, "", err
+	}
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return nil, "", err
+	}
+	return branches, strings.TrimSpace(string(head)), nil
+}
+
+// restoreHead recreates git's HEAD: attached to the same branch, or
+// detached at the translated commit. The "main" branch Create made is
+// dropped when git had no such branch.
+func (imp *gitImporter) restoreHead(head string, branches map[string]string) error {
+	if _, ok := branches["main"]; !ok {
+		if err := imp.repo.DeleteBranch("main"); err != nil {
+			return err
+		}
+	}
+
+	if ref, ok := strings.CutPrefix(head, "ref: refs/heads/"); ok {
+		if _, ok := branches[ref]; !ok {
+			// An unborn branch, e.g. a freshly initialised git repository.
+			if err := imp.repo.WriteBranch(ref, ""); err != nil {
+				return err
+			}
+		}
+		return imp.repo.AttachHead(ref)
+	}
+
+	hash, err := imp.commit(head)
+	if err != nil {
+		return fmt.Errorf("import HEAD: %w", err)
+	}
+	return imp.repo.DeattachHead(hash)
+}
+
+// writeMap saves the git → gitingo id mapping, sorted by git id.
+func (imp *gitImporter) writeMap() error {
+	lines := make([]string, 0, len(imp.ids))
+	for old, id := range imp.ids {
+		lines = append(lines, old+" "+id+"\n")
+	}
+	sort.Strings(lines)
+	return helper.WriteFileAtomic(
+		filepath.Join(imp.repo.GitDir, gitMapFile),
+		[]byte(strings.Join(lines, "")),
+		0644,
+	)
+}
+
+// checkout fills the index from HEAD. The working tree is only written
+// when it is empty, so importing in place over a git checkout never
+// overwrites local edits — status then shows them as unstaged changes.
+func (imp *gitImporter) checkout() error {
+	hash, err := imp.repo.ReadHead()
+	if err != nil || hash == "" {
+		return err
+	}
+
+	entries, err := os.ReadDir(imp.repo.WorkDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() != imp.repo.GitFolder && e.Name() != ".git" {
+			_, err := commit.ApplyCommitToIndex(imp.repo, hash)
+			return err
+		}
+	}
+	return commit.CheckoutCommit(imp.repo, hash)
+}
@@ -1,15 +1,51 @@
 package commands
 
 import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kasodeep/gitingo/helper"
 	"github.com/kasodeep/gitingo/repository"
 )
 
-// Init initialises a new gitingo repository at base.
-func Init(base string) error {
-	repo := repository.NewRepository(base)
+// Init initialises a new gitingo repository at base, hashing objects with
+// the named object format ("sha256" or "sha1"). An empty format means the
+// default, or the reference repository's format when there is one.
+//
+// With a reference, the new repository borrows that repository's objects
+// through objects/info/alternates instead of storing its own copies.
+func Init(base, objectFormat, reference string) error {
+	var ref *repository.Repository
+	if reference != "" {
+		if !filepath.IsAbs(reference) {
+			reference = filepath.Join(base, reference)
+		}
+		var err error
+		if ref, err = repository.GetRepository(reference); err != nil {
+			return fmt.Errorf("reference %s: %w", reference, err)
+		}
+		if objectFormat == "" {
+			objectFormat = string(ref.Objects.Format())
+		}
+	}
+
+	format, err := helper.ParseObjectFormat(objectFormat)
+	if err != nil {
+		return err
+	}
+	if ref != nil && ref.Objects.Format() != format {
+		return fmt.Errorf("reference %s uses %s objects, not %s", reference, ref.Objects.Format(), format)
+	}
+
+	repo := repository.NewRepository(base, format)
 	if err := repo.Create(); err != nil {
 		return err
 	}
+	if ref != nil {
+		if err := helper.AddAlternate(repo.GitDir, filepath.Join(ref.GitDir, "objects")); err != nil {
+			return err
+		}
+	}
 	p.Success("empty repository initialised on branch " + repo.CurrBranch)
 	return nil
 }
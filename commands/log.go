@@ -28,7 +28,7 @@ func Log(base string) error {
 func traverseCommitGraph(repo *repository.Repository, start string, w io.Writer) error {
 	hash := start
 	for i := 0; hash != ""; i++ {
-		c, err := commit.ParseCommit(repo.GitDir, hash)
+		c, err := commit.ParseCommit(repo, hash)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// move is one source renamed to its destination, both repo-relative.
+type move struct {
+	src, dst string
+}
+
+// Mv renames tracked files or directories on disk and in the index. With
+// one source, dst is the new name unless it is an existing directory; with
+// several, dst must be a directory and each source moves into it.
+// An existing destination file is only replaced with force.
+func Mv(base string, sources []string, dst string, force bool) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	idx, err := index.LoadIndex(repo)
+	if err != nil {
+		return err
+	}
+
+	wantDir := strings.HasSuffix(dst, "/")
+	dst = filepath.ToSlash(filepath.Clean(dst))
+	dstFull := filepath.Join(repo.WorkDir, filepath.FromSlash(dst))
+	intoDir := helper.IsDirectory(dstFull)
+	if (len(sources) > 1 || wantDir) && !intoDir {
+		return fmt.Errorf("destination '%s' is not a directory", dst)
+	}
+
+	var moves []move
+	for _, src := range sources {
+		src = filepath.ToSlash(filepath.Clean(src))
+		target := dst
+		if intoDir {
+			target = path.Join(dst, path.Base(src))
+		}
+		if err := checkMove(repo, idx, src, target, force); err != nil {
+			return err
+		}
+		moves = append(moves, move{src, target})
+	}
+
+	for i, m := range moves {
+		if err := os.Rename(
+			filepath.Join(repo.WorkDir, filepath.FromSlash(m.src)),
+			filepath.Join(repo.WorkDir, filepath.FromSlash(m.dst)),
+		); err != nil {
+			undoMoves(repo, moves[:i])
+			return err
+		}
+		renameEntries(idx, m)
+	}
+
+	// Every rename lands in the index in one atomic write; if it fails the
+	// files go back where they were.
+	if err := idx.WriteLocked(repo, lock); err != nil {
+		undoMoves(repo, moves)
+		return err
+	}
+	for _, m := range moves {
+		p.Info(fmt.Sprintf("renamed '%s' -> '%s'", m.src, m.dst))
+	}
+	return nil
+}
+
+// checkMove validates a single rename before anything is touched.
+func checkMove(repo *repository.Repository, idx *index.Index, src, dst string, force bool) error {
+	srcFull := filepath.Join(repo.WorkDir, filepath.FromSlash(src))
+	dstFull := filepath.Join(repo.WorkDir, filepath.FromSlash(dst))
+
+	info, err := os.Lstat(srcFull)
+	if err != nil {
+		return fmt.Errorf("bad source '%s': no such file or directory", src)
+	}
+	if src == dst {
+		return fmt.Errorf("cannot move '%s' onto itself", src)
+	}
+	if strings.HasPrefix(dst, src+"/") {
+		return fmt.Errorf("cannot move directory '%s' into itself", src)
+	}
+	if !tracksPath(idx, src) {
+		return fmt.Errorf("not under version control: '%s'", src)
+	}
+	if !helper.IsDirectory(filepath.Dir(dstFull)) {
+		return fmt.Errorf("destination directory for '%s' does not exist", dst)
+	}
+
+	if dinfo, err := os.Lstat(dstFull); err == nil {
+		switch {
+		case info.IsDir() || dinfo.IsDir():
+			return fmt.Errorf("destination '%s' already exists", dst)
+		case !force:
+			return fmt.Errorf("destination '%s' already exists (use -f to overwrite)", dst)
+		}
+	}
+	return nil
+}
+
+// tracksPath reports whether path is a tracked file or a directory that
+// holds tracked files.
+func tracksPath(idx *index.Index, p string) bool {
+	if _, ok := idx.Entries[p]; ok {
+		return true
+	}
+	for e := range idx.Entries {
+		if strings.HasPrefix(e, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// renameEntries re-keys the index entries at or under m.src to live under
+// m.dst, replacing any entry already at the destination.
+func renameEntries(idx *index.Index, m move) {
+	var moved []index.IndexEntry
+	for p, e := range idx.Entries {
+		if p == m.src || strings.HasPrefix(p, m.src+"/") {
+			moved = append(moved, e)
+		}
+	}
+	delete(idx.Entries, m.dst)
+	for _, e := range moved {
+		delete(idx.Entries, e.Path)
+		e.Path = m.dst + strings.TrimPrefix(e.Path, m.src)
+		idx.Entries[e.Path] = e
+	}
+}
+
+// undoMoves renames already-moved paths back, newest first.
+func undoMoves(repo *repository.Repository, moves []move) {
+	for i := len(moves) - 1; i >= 0; i-- {
+		os.Rename(
+			filepath.Join(repo.WorkDir, filepath.FromSlash(moves[i].dst)),
+			filepath.Join(repo.WorkDir, filepath.FromSlash(moves[i].src)),
+		)
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// DefaultPruneExpire is how old an unreachable loose object must be before
+// prune removes it. Another process may have just written an object it
+// has not referenced yet; the grace period keeps prune from pulling it
+// out from under that writer.
+const DefaultPruneExpire = 14 * 24 * time.Hour
+
+// Prune removes loose objects that are unreachable from every branch,
+// HEAD, reflog entry and staged file, and older than expire. With dryRun
+// set it only lists what would be removed. Packed objects are never
+// touched.
+func Prune(base string, expire time.Duration, dryRun bool) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+
+	reachable, err := pruneReachable(repo)
+	if err != nil {
+		return fmt.Errorf("prune: %w", err)
+	}
+
+	loose, err := helper.LooseObjects(repo.GitDir)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-expire)
+	pruned := 0
+	for _, hash := range loose {
+		if reachable[hash] {
+			continue
+		}
+		info, err := helper.StatLoose(repo.GitDir, hash)
+		if err != nil {
+			continue // removed by a concurrent prune or gc
+		}
+		if info.ModTime().After(cutoff) {
+			continue
+		}
+
+		if dryRun {
+			typ, _, _ := repo.Objects.Read(hash)
+			p.Info(hash + " " + typ)
+		} else if err := helper.RemoveLoose(repo.GitDir, hash); err != nil {
+			return fmt.Errorf("prune: %w", err)
+		}
+		pruned++
+	}
+
+	switch {
+	case dryRun:
+		p.Info(fmt.Sprintf("would prune %d objects", pruned))
+	case pruned == 0:
+		p.Info("nothing to prune")
+	default:
+		p.Success(fmt.Sprintf("pruned %d unreachable objects", pruned))
+	}
+	return nil
+}
+
+// pruneReachable returns the set of objects prune must keep: everything
+// reachable from the refs and reflogs, plus the staged files in the index.
+// A missing object under a ref is an error, but one only a reflog entry
+// leads to is just stale history: it is skipped with a warning.
+func pruneReachable(repo *repository.Repository) (map[string]bool, error) {
+	tips, err := refTips(repo)
+	if err != nil {
+		return nil, err
+	}
+	logged, err := repo.RefLogHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	w := newReachWalk(repo)
+	if err := w.walk(tips); err != nil {
+		return nil, err
+	}
+	for _, hash := range logged {
+		if err := w.walk([]string{hash}); err != nil {
+			p.Warn(fmt.Sprintf("skipping reflog entry %s: %v", abbrev(hash), err))
+		}
+	}
+	keep := make(map[string]bool, len(w.out))
+	for _, o := range w.out {
+		keep[o.Hash] = true
+	}
+
+	// Staged chunk lists need their chunks kept too, not just the list.
+	staged, err := indexObjects(repo, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range staged {
+		keep[o.Hash] = true
+	}
+	return keep, nil
+}
+
+// ParseExpire parses a --expire value: "now", a Go duration such as
+// "36h", or a whole number of days or weeks such as "30d" or "2w".
+func ParseExpire(s string) (time.Duration, error) {
+	if s == "now" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid expire %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid expire %q", s)
+	}
+	return d, nil
+}
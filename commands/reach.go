@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/kasodeep/gitingo/commit"
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+	"github.com/kasodeep/gitingo/tree"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Reachability
+// ─────────────────────────────────────────────────────────────────────────────
+
+// reachObject is one object found while walking history from the refs.
+// Path is the repo-relative path a blob or tree was first seen at.
+type reachObject struct {
+	Hash string
+	Type string
+	Path string
+}
+
+// refTips returns the distinct commit hashes named by refs/heads/* and HEAD.
+// Unborn branches (empty ref files) are skipped.
+func refTips(repo *repository.Repository) ([]string, error) {
+	branches, err := repo.ListBranches()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var tips []string
+	add := func(hash string) {
+		if hash != "" && !seen[hash] {
+			seen[hash] = true
+			tips = append(tips, hash)
+		}
+	}
+
+	for _, b := range branches {
+		hash, err := repo.ReadBranch(b)
+		if err != nil {
+			return nil, err
+		}
+		add(hash)
+	}
+	head, err := repo.ReadHead()
+	if err != nil {
+		return nil, err
+	}
+	add(head)
+	return tips, nil
+}
+
+// chunkedBlob lists the file object a tree entry points at: a plain blob,
+// or a chunk list followed by the chunks it names. A file object that
+// cannot be opened is reported as a blob and left for the caller to trip
+// over, as before chunking existed.
+func chunkedBlob(repo *repository.Repository, hash, path string, seen map[string]bool) []reachObject {
+	objType, _, r, err := repo.Objects.Open(hash)
+	if err != nil {
+		return []reachObject{{Hash: hash, Type: "blob", Path: path}}
+	}
+	defer r.Close()
+	if objType != helper.ChunksType {
+		return []reachObject{{Hash: hash, Type: objType, Path: path}}
+	}
+
+	out := []reachObject{{Hash: hash, Type: helper.ChunksType, Path: path}}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return out
+	}
+	chunks, err := helper.ParseChunks(content)
+	if err != nil {
+		return out
+	}
+	for _, c := range chunks {
+		if !seen[c.Hash] {
+			seen[c.Hash] = true
+			out = append(out, reachObject{Hash: c.Hash, Type: "blob", Path: path})
+		}
+	}
+	return out
+}
+
+// indexObjects lists the file objects staged in the index, in path order,
+// with chunk lists expanded to their chunks like walkReachable does.
+// Hashes already in seen are skipped; the rest are added to it.
+func indexObjects(repo *repository.Repository, seen map[string]bool) ([]reachObject, error) {
+	idx, err := index.LoadIndex(repo)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(idx.Entries))
+	for p := range idx.Entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var out []reachObject
+	for _, p := range paths {
+		hash := idx.Entries[p].Hash
+		if !seen[hash] {
+			seen[hash] = true
+			out = append(out, chunkedBlob(repo, hash, p, seen)...)
+		}
+	}
+	return out, nil
+}
+
+// walkReachable returns every object reachable from tips: each commit,
+// followed by its tree and everything under it. Objects are listed once,
+// in the order they are first seen. A missing object aborts the walk.
+func walkReachable(repo *repository.Repository, tips []string) ([]reachObject, error) {
+	w := newReachWalk(repo)
+	if err := w.walk(tips); err != nil {
+		return nil, err
+	}
+	return w.out, nil
+}
+
+// reachWalk is walkReachable's state, kept across several walks so that
+// history shared between them is only visited once.
+type reachWalk struct {
+	repo *repository.Repository
+	seen map[string]bool
+	out  []reachObject
+}
+
+func newReachWalk(repo *repository.Repository) *reachWalk {
+	return &reachWalk{repo: repo, seen: make(map[string]bool)}
+}
+
+// walk adds everything reachable from tips to w.out. On error, the objects
+// found before the missing one stay listed.
+func (w *reachWalk) walk(tips []string) error {
+	queue := append([]string(nil), tips...)
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if w.seen[hash] {
+			continue
+		}
+		w.seen[hash] = true
+		w.out = append(w.out, reachObject{Hash: hash, Type: "commit"})
+
+		c, err := commit.ParseCommit(w.repo, hash)
+		if err != nil {
+			return err
+		}
+		if c.Tree == "" {
+			return fmt.Errorf("commit %s has no tree", abbrev(hash))
+		}
+		if err := w.walkTree(c.Tree, ""); err != nil {
+			return err
+		}
+		queue = append(queue, c.Parents...)
+	}
+	return nil
+}
+
+// walkTree lists the tree hash, found at dir, and everything under it.
+func (w *reachWalk) walkTree(hash, dir string) error {
+	if w.seen[hash] {
+		return nil
+	}
+	w.seen[hash] = true
+	w.out = append(w.out, reachObject{Hash: hash, Type: "tree", Path: dir})
+
+	entries, err := tree.ReadEntries(w.repo, hash)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := path.Join(dir, e.Name)
+		if e.IsDir() {
+			if err := w.walkTree(e.Hash, p); err != nil {
+				return err
+			}
+			continue
+		}
+		if !w.seen[e.Hash] {
+			w.seen[e.Hash] = true
+			w.out = append(w.out, chunkedBlob(w.repo, e.Hash, p, w.seen)...)
+		}
+	}
+	return nil
+}
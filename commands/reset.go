@@ -8,7 +8,7 @@ import (
 	"github.com/kasodeep/gitingo/repository"
 )
 
-// Reset moves HEAD to hash using the given mode.
+// Reset moves HEAD to hash using the given mode. hash may be abbreviated.
 //
 //	soft  — HEAD only
 //	mixed — HEAD + index
@@ -18,7 +18,11 @@ func Reset(base, hash, mode string) error {
 	if err != nil {
 		return err
 	}
-	if err := helper.VerifyObject(repo.GitDir, hash, "commit"); err != nil {
+	hash, err = helper.ResolveHash(repo.Objects, hash)
+	if err != nil {
+		return err
+	}
+	if err := helper.Verify(repo.Objects, hash, "commit"); err != nil {
 		return err
 	}
 
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+	"github.com/kasodeep/gitingo/tree"
+)
+
+// Restore copies the files named by pathspecs back into the working
+// directory and/or the index, leaving HEAD and every other file alone.
+// Directories are restored recursively.
+//
+//	--worktree (default)  from the index, or from source
+//	--staged              from HEAD, or from source
+//	both                  from HEAD, or from source
+//
+// A path the source does not have is removed from the restored side, so
+// restoring a newly added file with --staged unstages it.
+func Restore(base string, pathspecs []string, source string, staged, worktree bool) error {
+	if !staged && !worktree {
+		worktree = true
+	}
+
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	idx, err := index.LoadIndex(repo)
+	if err != nil {
+		return err
+	}
+
+	src, from, err := restoreSource(repo, idx, source, staged)
+	if err != nil {
+		return err
+	}
+
+	known := index.NewIndex()
+	for _, e := range []*index.Index{src, idx} {
+		for p, entry := range e.Entries {
+			known.Entries[p] = entry
+		}
+	}
+	paths, err := matchPathspecs(known, pathspecs, true)
+	if err != nil {
+		return err
+	}
+
+	// The working directory goes first: Index.Write drops entries whose
+	// files are missing.
+	if worktree {
+		for _, file := range paths {
+			if err := restoreFile(repo, src, file); err != nil {
+				return err
+			}
+		}
+	}
+	if staged {
+		for _, file := range paths {
+			if e, ok := src.Entries[file]; ok {
+				idx.Entries[file] = e
+			} else {
+				delete(idx.Entries, file)
+			}
+		}
+		if err := idx.WriteLocked(repo, lock); err != nil {
+			return err
+		}
+	}
+
+	p.Info(fmt.Sprintf("updated %d path(s) from %s", len(paths), from))
+	return nil
+}
+
+// restoreSource returns the snapshot to restore from and a description of
+// it. An explicit source is a branch, "HEAD" or a commit id.
+func restoreSource(repo *repository.Repository, idx *index.Index, source string, staged bool) (*index.Index, string, error) {
+	switch {
+	case source != "":
+		hash, err := resolveRev(repo, source)
+		if err != nil {
+			return nil, "", err
+		}
+		src, err := indexFromCommit(repo, hash)
+		return src, abbrev(hash), err
+	case staged:
+		return resolveCommitIndex(repo), "HEAD", nil
+	default:
+		return idx, "the index", nil
+	}
+}
+
+// resolveRev turns "HEAD", a branch name or a (possibly abbreviated)
+// commit id into a commit hash.
+func resolveRev(repo *repository.Repository, rev string) (string, error) {
+	if rev == "HEAD" {
+		hash, err := repo.ReadHead()
+		if err != nil || hash == "" {
+			return "", fmt.Errorf("no commit yet")
+		}
+		return hash, nil
+	}
+	if repo.IsBranchExists(rev) {
+		hash, err := repo.ReadBranch(rev)
+		if err != nil || hash == "" {
+			return "", fmt.Errorf("branch %s has no commits", rev)
+		}
+		return hash, nil
+	}
+	hash, err := helper.ResolveHash(repo.Objects, rev)
+	if err != nil {
+		return "", err
+	}
+	if err := helper.Verify(repo.Objects, hash, "commit"); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// restoreFile makes one working-directory file match src: rewritten from
+// its blob, or deleted when src does not have it.
+func restoreFile(repo *repository.Repository, src *index.Index, file string) error {
+	if e, ok := src.Entries[file]; ok {
+		return tree.WriteFile(repo, file, e.Mode, e.Hash)
+	}
+	err := os.Remove(filepath.Join(repo.WorkDir, filepath.FromSlash(file)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	removeEmptyParents(repo.WorkDir, path.Dir(file))
+	return nil
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kasodeep/gitingo/index"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// Rm removes tracked files from the index and, unless cached is set, from
+// the working directory. Directories need recursive. Without force, a file
+// is refused if removing it would lose work:
+//
+//	rm           — staged or unstaged modifications
+//	rm --cached  — staged content that matches neither HEAD nor the file
+func Rm(base string, pathspecs []string, cached, recursive, force bool) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	idx, err := index.LoadIndex(repo)
+	if err != nil {
+		return err
+	}
+
+	paths, err := matchPathspecs(idx, pathspecs, recursive)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		head := resolveCommitIndex(repo)
+		wd, err := index.LoadWorkingDirIndex(repo)
+		if err != nil {
+			return err
+		}
+		if err := checkRemovable(paths, idx, head, wd, cached); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range paths {
+		delete(idx.Entries, file)
+	}
+	if err := idx.WriteLocked(repo, lock); err != nil {
+		return err
+	}
+
+	for _, file := range paths {
+		if !cached {
+			full := filepath.Join(repo.WorkDir, filepath.FromSlash(file))
+			if err := os.Remove(full); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			removeEmptyParents(repo.WorkDir, path.Dir(file))
+		}
+		p.Info(fmt.Sprintf("rm '%s'", file))
+	}
+	return nil
+}
+
+// matchPathspecs expands pathspecs — files, or with recursive directories —
+// to the index entries they name, sorted. A pathspec naming nothing tracked
+// is an error.
+func matchPathspecs(idx *index.Index, pathspecs []string, recursive bool) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, spec := range pathspecs {
+		spec = filepath.ToSlash(filepath.Clean(spec))
+		if _, ok := idx.Entries[spec]; ok {
+			seen[spec] = true
+			continue
+		}
+
+		var under []string
+		for p := range idx.Entries {
+			if spec == "." || strings.HasPrefix(p, spec+"/") {
+				under = append(under, p)
+			}
+		}
+		if len(under) == 0 {
+			return nil, fmt.Errorf("pathspec '%s' did not match any tracked files", spec)
+		}
+		if !recursive {
+			return nil, fmt.Errorf("not removing '%s' recursively without -r", spec)
+		}
+		for _, p := range under {
+			seen[p] = true
+		}
+	}
+
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// checkRemovable refuses paths whose removal would discard changes. A file
+// already gone from the working directory has nothing left to lose, so it
+// can always be removed, whatever is staged for it.
+func checkRemovable(paths []string, idx, head, wd *index.Index, cached bool) error {
+	var problems []string
+	for _, file := range paths {
+		staged := idx.Entries[file]
+		h, inHead := head.Entries[file]
+		w, onDisk := wd.Entries[file]
+
+		if !onDisk {
+			continue
+		}
+		stagedChange := !inHead || h.Hash != staged.Hash || h.Mode != staged.Mode
+		localChange := w.Hash != staged.Hash || w.Mode != staged.Mode
+
+		switch {
+		case stagedChange && localChange:
+			problems = append(problems, file+": staged content different from both the file and HEAD")
+		case !cached && stagedChange:
+			problems = append(problems, file+": has changes staged in the index")
+		case !cached && localChange:
+			problems = append(problems, file+": has local modifications")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("refusing to remove (use --cached to keep the file, or -f to force):\n\t%s",
+			strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// removeEmptyParents deletes dir and its ancestors, up to the work tree
+// root, for as long as they are empty.
+func removeEmptyParents(workDir, dir string) {
+	for dir != "." && dir != "/" && dir != "" {
+		if os.Remove(filepath.Join(workDir, filepath.FromSlash(dir))) != nil {
+			return
+		}
+		dir = path.Dir(dir)
+	}
+}
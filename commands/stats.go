@@ -0,0 +1,172 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kasodeep/gitingo/commit"
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// statsTop is how many blobs and trees Stats lists in each ranking.
+const statsTop = 10
+
+// statEntry is one ranked object in the stats report.
+type statEntry struct {
+	hash string
+	path string
+	n    int64 // size in bytes for blobs, depth for trees
+}
+
+// Stats prints a summary of the history reachable from the refs: object
+// totals, the largest blobs, the deepest trees and how many commits each
+// branch contains.
+func Stats(base string) error {
+	repo, err := repository.GetRepository(base)
+	if err != nil {
+		return err
+	}
+	tips, err := refTips(repo)
+	if err != nil {
+		return err
+	}
+	objs, err := walkReachable(repo, tips)
+	if err != nil {
+		return fmt.Errorf("stats: %w", err)
+	}
+
+	var commits, trees int
+	var blobs []statEntry
+	var blobBytes int64
+	deepest := make(map[string]statEntry) // path → deepest tree seen there
+	pieces := make(map[string]bool)       // chunks of chunk lists seen so far
+
+	for _, o := range objs {
+		switch o.Type {
+		case "commit":
+			commits++
+		case "tree":
+			trees++
+			depth := int64(0)
+			if o.Path != "" {
+				depth = int64(strings.Count(o.Path, "/") + 1)
+			}
+			if _, seen := deepest[o.Path]; !seen {
+				deepest[o.Path] = statEntry{o.Hash, o.Path, depth}
+			}
+		case helper.ChunksType, "blob":
+			// A chunked file counts once, at its full size; the chunks
+			// walkReachable lists after it are not files of their own.
+			if pieces[o.Hash] {
+				continue
+			}
+			if o.Type == helper.ChunksType {
+				if err := markChunks(repo, o.Hash, pieces); err != nil {
+					return fmt.Errorf("stats: %w", err)
+				}
+			}
+			size, r, err := helper.OpenBlob(repo.Objects, o.Hash)
+			if err != nil {
+				return fmt.Errorf("stats: %w", err)
+			}
+			r.Close()
+			blobs = append(blobs, statEntry{o.Hash, o.Path, size})
+			blobBytes += size
+		}
+	}
+
+	p.Info(fmt.Sprintf("commits: %d", commits))
+	p.Info(fmt.Sprintf("trees:   %d", trees))
+	p.Info(fmt.Sprintf("blobs:   %d (%d KiB)", len(blobs), kib(blobBytes)))
+
+	p.Info("\nlargest blobs:")
+	for _, b := range topEntries(blobs) {
+		p.Info(fmt.Sprintf("  %10d B  %s  %s", b.n, p.CommitHash(abbrev(b.hash)), b.path))
+	}
+
+	p.Info("\ndeepest trees:")
+	treeList := make([]statEntry, 0, len(deepest))
+	for _, t := range deepest {
+		treeList = append(treeList, t)
+	}
+	for _, t := range topEntries(treeList) {
+		path := t.path
+		if path == "" {
+			path = "(root)"
+		}
+		p.Info(fmt.Sprintf("  %12d  %s  %s", t.n, p.CommitHash(abbrev(t.hash)), path))
+	}
+
+	p.Info("\ncommits per branch:")
+	branches, err := repo.ListBranches()
+	if err != nil {
+		return err
+	}
+	sort.Strings(branches)
+	for _, b := range branches {
+		hash, err := repo.ReadBranch(b)
+		if err != nil {
+			return err
+		}
+		n, err := countCommits(repo, hash)
+		if err != nil {
+			return fmt.Errorf("stats: %w", err)
+		}
+		p.Info(fmt.Sprintf("  %12d  %s", n, p.Branch(b)))
+	}
+	return nil
+}
+
+// markChunks adds the chunks of the chunk list hash to pieces.
+func markChunks(repo *repository.Repository, hash string, pieces map[string]bool) error {
+	_, content, err := repo.Objects.Read(hash)
+	if err != nil {
+		return err
+	}
+	chunks, err := helper.ParseChunks(content)
+	if err != nil {
+		return fmt.Errorf("chunk list %s: %w", abbrev(hash), err)
+	}
+	for _, c := range chunks {
+		pieces[c.Hash] = true
+	}
+	return nil
+}
+
+// topEntries returns the statsTop largest entries, ties broken by path.
+func topEntries(entries []statEntry) []statEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].n != entries[j].n {
+			return entries[i].n > entries[j].n
+		}
+		return entries[i].path < entries[j].path
+	})
+	if len(entries) > statsTop {
+		entries = entries[:statsTop]
+	}
+	return entries
+}
+
+// countCommits returns how many commits are reachable from tip, following
+// every parent of merge commits. An unborn branch has none.
+func countCommits(repo *repository.Repository, tip string) (int, error) {
+	seen := make(map[string]bool)
+	queue := []string{tip}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if hash == "" || seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		c, err := commit.ParseCommit(repo, hash)
+		if err != nil {
+			return 0, err
+		}
+		queue = append(queue, c.Parents...)
+	}
+	return len(seen), nil
+}
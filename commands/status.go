@@ -44,7 +44,10 @@ func Check(repo *repository.Repository) error {
 	if err != nil {
 		return err
 	}
-	wdIdx := index.LoadWorkingDirIndex(repo)
+	wdIdx, err := index.LoadWorkingDirIndex(repo)
+	if err != nil {
+		return err
+	}
 
 	printStagedChanges(DiffIndexes(resolveCommitIndex(repo), idx))
 	printNotStagedChanges(DiffIndexes(idx, wdIdx))
@@ -105,6 +108,7 @@ func DiffIndexes(base, other *index.Index) []Change {
 }
 
 // FindUntracked returns files present in the working directory but absent from the index.
+// Ignored files never reach wdIdx: LoadWorkingDirIndex skips them.
 func FindUntracked(idx, wdIdx *index.Index) []Change {
 	var changes []Change
 	for path, e := range wdIdx.Entries {
@@ -58,9 +58,14 @@ func resetBranch(repo *repository.Repository) error {
 	return commit.CheckoutCommit(repo, hash)
 }
 
-// switchHash detaches HEAD and checks out a specific commit.
+// switchHash detaches HEAD and checks out a specific, possibly
+// abbreviated, commit hash.
 func switchHash(repo *repository.Repository, hash string) error {
-	if err := helper.VerifyObject(repo.GitDir, hash, "commit"); err != nil {
+	hash, err := helper.ResolveHash(repo.Objects, hash)
+	if err != nil {
+		return err
+	}
+	if err := helper.Verify(repo.Objects, hash, "commit"); err != nil {
 		return err
 	}
 	if err := commit.CheckoutCommit(repo, hash); err != nil {
@@ -88,8 +93,12 @@ func checkSwitchSafety(repo *repository.Repository) error {
 	if err != nil {
 		return err
 	}
+	wdIdx, err := index.LoadWorkingDirIndex(repo)
+	if err != nil {
+		return err
+	}
 	staged := DiffIndexes(resolveCommitIndex(repo), idx)
-	notStaged := DiffIndexes(idx, index.LoadWorkingDirIndex(repo))
+	notStaged := DiffIndexes(idx, wdIdx)
 
 	if len(staged) > 0 || len(notStaged) > 0 {
 		return ErrDirtyWorkTree
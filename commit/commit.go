@@ -5,11 +5,11 @@ package commit
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/kasodeep/gitingo/helper"
 	"github.com/kasodeep/gitingo/index"
 	"github.com/kasodeep/gitingo/repository"
 	"github.com/kasodeep/gitingo/tree"
@@ -27,7 +27,15 @@ type Commit struct {
 	Author    string
 	Email     string
 	Timestamp int64
+	Timezone  string // author's UTC offset, e.g. "+0530"
 	Msg       string
+
+	// Committer fields; identical to the author's unless the commit was
+	// imported from a history that recorded them separately.
+	Committer         string
+	CommitterEmail    string
+	CommitTimestamp   int64
+	CommitterTimezone string
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -35,9 +43,15 @@ type Commit struct {
 // ─────────────────────────────────────────────────────────────────────────────
 
 // ParseCommit reads a commit object by hash and returns its parsed fields.
-func ParseCommit(gitDir, hash string) (*Commit, error) {
-	content, ok := helper.ReadObject(gitDir, hash)
-	if !ok {
+// Parsed commits are kept in repo.Cache; each call returns a fresh copy
+// the caller is free to modify.
+func ParseCommit(repo *repository.Repository, hash string) (*Commit, error) {
+	if v, ok := repo.Cache.Get("commit", hash); ok {
+		return cloneCommit(v.(*Commit)), nil
+	}
+
+	_, content, err := repo.Objects.Read(hash)
+	if err != nil {
 		return nil, fmt.Errorf("commit not found: %s", hash)
 	}
 
@@ -60,7 +74,16 @@ func ParseCommit(gitDir, hash string) (*Commit, error) {
 			"\n",
 		)
 	}
-	return c, nil
+
+	repo.Cache.Add("commit", hash, c)
+	return cloneCommit(c), nil
+}
+
+// cloneCommit copies c so cached commits are never shared with callers.
+func cloneCommit(c *Commit) *Commit {
+	clone := *c
+	clone.Parents = slices.Clone(c.Parents)
+	return &clone
 }
 
 // parseHeader dispatches a single header line into the Commit fields.
@@ -73,36 +96,46 @@ func parseHeader(c *Commit, line string) {
 		c.Parents = append(c.Parents, line[7:])
 
 	case strings.HasPrefix(line, "author "):
-		// Format: "author Name <email> <unix-ts> <tz>"
-		rest := line[len("author "):]
-		gt := strings.LastIndex(rest, ">")
-		if gt == -1 {
-			return
-		}
-		parts := strings.SplitN(rest[:gt+1], "<", 2)
-		c.Author = strings.TrimSpace(parts[0])
-		c.Email = strings.TrimSuffix(parts[1], ">")
+		c.Author, c.Email, c.Timestamp, c.Timezone = parseIdent(line[len("author "):])
 
-		if meta := strings.Fields(rest[gt+1:]); len(meta) > 0 {
-			c.Timestamp, _ = strconv.ParseInt(meta[0], 10, 64)
-		}
+	case strings.HasPrefix(line, "committer "):
+		c.Committer, c.CommitterEmail, c.CommitTimestamp, c.CommitterTimezone = parseIdent(line[len("committer "):])
 	}
 }
 
-// ReadTreeHash returns the root tree hash for a commit without
-// fully parsing it. Used in hot paths (status, diff) that only
-// need the tree.
+// parseIdent splits an identity header value: "Name <email> <unix-ts> <tz>".
+// A malformed value yields zero values.
+func parseIdent(rest string) (name, email string, ts int64, tz string) {
+	gt := strings.LastIndex(rest, ">")
+	if gt == -1 {
+		return
+	}
+	parts := strings.SplitN(rest[:gt+1], "<", 2)
+	if len(parts) != 2 {
+		return
+	}
+	name = strings.TrimSpace(parts[0])
+	email = strings.TrimSuffix(parts[1], ">")
+
+	meta := strings.Fields(rest[gt+1:])
+	if len(meta) > 0 {
+		ts, _ = strconv.ParseInt(meta[0], 10, 64)
+	}
+	if len(meta) > 1 {
+		tz = meta[1]
+	}
+	return
+}
+
+// ReadTreeHash returns the root tree hash for a commit, or "" if the
+// commit cannot be read. Used in hot paths (status, diff) that only need
+// the tree; repeated lookups are served from repo.Cache.
 func ReadTreeHash(repo *repository.Repository, commitHash string) string {
-	content, ok := helper.ReadObject(repo.GitDir, commitHash)
-	if !ok {
+	c, err := ParseCommit(repo, commitHash)
+	if err != nil {
 		return ""
 	}
-	for _, line := range bytes.Split(content, []byte{'\n'}) {
-		if bytes.HasPrefix(line, []byte("tree ")) {
-			return strings.TrimSpace(string(line[5:]))
-		}
-	}
-	return ""
+	return strings.TrimSpace(c.Tree)
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -111,8 +144,8 @@ func ReadTreeHash(repo *repository.Repository, commitHash string) string {
 
 // WriteCommitObject serialises a commit and writes it to the object store.
 // Returns the new commit's hash.
-func WriteCommitObject(gitDir, treeHash, parentHash, message string) string {
-	cfg := repository.ReadConfig(gitDir)
+func WriteCommitObject(repo *repository.Repository, treeHash, parentHash, message string) (string, error) {
+	cfg := repository.ReadConfig(repo.GitDir)
 	ts := strconv.FormatInt(time.Now().Unix(), 10)
 
 	var buf bytes.Buffer
@@ -124,7 +157,7 @@ func WriteCommitObject(gitDir, treeHash, parentHash, message string) string {
 	fmt.Fprintf(&buf, "committer %s <%s> %s +0000\n", cfg.Name, cfg.Email, ts)
 	fmt.Fprintf(&buf, "\n%s\n", message)
 
-	return helper.WriteObject(gitDir, "commit", buf.Bytes())
+	return repo.Objects.Write("commit", buf.Bytes())
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -132,30 +165,48 @@ func WriteCommitObject(gitDir, treeHash, parentHash, message string) string {
 // ─────────────────────────────────────────────────────────────────────────────
 
 // CheckoutCommit updates the index and working directory to match commitHash.
-// Used by switch and reset.
+// Used by switch and reset. The index stays locked throughout, so no other
+// process can stage files while the working directory is being rewritten.
 func CheckoutCommit(repo *repository.Repository, hash string) error {
 	treeHash := ReadTreeHash(repo, hash)
 	if treeHash == "" {
 		return fmt.Errorf("cannot resolve tree for commit %s", hash[:7])
 	}
 
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
 	root, err := tree.ParseTree(repo, treeHash, "")
 	if err != nil {
 		return err
 	}
 
-	idx := index.NewIndex()
-	tree.TreeToIndex(idx, root, "")
-	if err := idx.Write(repo); err != nil {
+	// Files go down before the index: Index.Write drops entries whose
+	// files are missing, which would lose every file new to the work tree.
+	if err := tree.WriteReverse(repo, root, ""); err != nil {
 		return err
 	}
 
-	return tree.WriteReverse(repo, root, "")
+	// The files were just written from these blobs, so their stat data can
+	// vouch for them until they change.
+	idx := index.NewIndex()
+	tree.TreeToIndex(idx, root, "")
+	idx.RecordStats(repo)
+	return idx.WriteLocked(repo, lock)
 }
 
 func ApplyCommitToIndex(repo *repository.Repository, commitHash string) (*tree.TreeNode, error) {
 	treeHash := ReadTreeHash(repo, commitHash)
 
+	lock, err := index.LockIndex(repo)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
 	root, err := tree.ParseTree(repo, treeHash, "")
 	if err != nil {
 		return nil, err
@@ -163,7 +214,9 @@ func ApplyCommitToIndex(repo *repository.Repository, commitHash string) (*tree.T
 
 	idx := index.NewIndex()
 	tree.TreeToIndex(idx, root, "")
-	idx.Write(repo)
+	if err := idx.WriteLocked(repo, lock); err != nil {
+		return nil, err
+	}
 
 	return root, nil
 }
@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Alternates
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// objects/info/alternates lists, one per line, other repositories' objects
+// directories to borrow from. An object missing locally is looked up in
+// each of them, so clones of one large repository can share a single copy
+// of its history. Relative paths are taken relative to this repository's
+// objects directory; blank lines and "#" comments are ignored.
+//
+// Borrowed objects are never copied: pruning the repository that owns them
+// breaks every repository that borrows from it.
+
+const alternatesFile = "alternates"
+
+// maxAlternateDepth bounds how far alternates of alternates are followed,
+// as git does, so a cycle cannot recurse forever.
+const maxAlternateDepth = 5
+
+// Alternates returns the git directories whose objects gitDir may borrow,
+// nearest first, following their own alternates in turn. Entries that do
+// not exist are skipped.
+func Alternates(gitDir string) []string {
+	seen := map[string]bool{filepath.Clean(gitDir): true}
+	var out []string
+
+	var visit func(dir string, depth int)
+	visit = func(dir string, depth int) {
+		if depth > maxAlternateDepth {
+			return
+		}
+		for _, alt := range readAlternates(dir) {
+			if seen[alt] {
+				continue
+			}
+			seen[alt] = true
+			out = append(out, alt)
+			visit(alt, depth+1)
+		}
+	}
+	visit(gitDir, 1)
+	return out
+}
+
+// alternateLists caches readAlternates per alternates file.
+var alternateLists = newStatCache[[]string]()
+
+// readAlternates parses gitDir's alternates file into git directories —
+// the parents of the listed objects directories. The file is only re-read
+// when it changes.
+func readAlternates(gitDir string) []string {
+	objects := filepath.Join(gitDir, "objects")
+	path := filepath.Join(objects, "info", alternatesFile)
+	dirs, _ := alternateLists.get(path, func() ([]string, error) {
+		return parseAlternates(objects, path), nil
+	})
+	return dirs
+}
+
+// parseAlternates reads the alternates file at path, whose relative
+// entries are relative to the objects directory.
+func parseAlternates(objects, path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(objects, line)
+		}
+		if IsDirectory(line) {
+			dirs = append(dirs, filepath.Dir(filepath.Clean(line)))
+		}
+	}
+	return dirs
+}
+
+// AddAlternate appends objectsDir to gitDir's alternates file. objectsDir
+// must be an existing objects directory; it is stored as an absolute path.
+func AddAlternate(gitDir, objectsDir string) error {
+	abs, err := filepath.Abs(objectsDir)
+	if err != nil {
+		return err
+	}
+	if filepath.Base(abs) != "objects" || !IsDirectory(abs) {
+		return fmt.Errorf("%s: not an objects directory", objectsDir)
+	}
+
+	info := filepath.Join(gitDir, "objects", "info")
+	if err := os.MkdirAll(info, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(info, alternatesFile)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		existing = append(existing, '\n')
+	}
+	return WriteFileAtomic(path, append(existing, abs+"\n"...), 0644)
+}
@@ -0,0 +1,235 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Chunked blobs
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// A large file can be stored as a chunk list instead of a single blob: the
+// content is cut into chunks at content-defined boundaries, each chunk is
+// stored as an ordinary blob, and a "chunks" object lists them in order:
+//
+//	<chunk-hash> <size>\n   one line per chunk
+//
+// Boundaries depend only on the bytes near them (a gear rolling hash, as in
+// FastCDC), so an edit in the middle of a file changes the chunks around
+// the edit and leaves the rest — and their object ids — untouched.
+//
+// Trees and the index refer to the chunk list wherever they would refer to
+// the blob. OpenBlob and ReadBlob reassemble either kind transparently.
+
+// ChunksType is the object type of a chunk list.
+const ChunksType = "chunks"
+
+// Chunk size bounds. A boundary is declared where the rolling hash has its
+// low chunkMaskBits bits clear, giving ~1 MiB chunks on average.
+const (
+	minChunkSize  = 256 << 10
+	maxChunkSize  = 4 << 20
+	chunkMaskBits = 20
+)
+
+// gearTable maps each byte to a pseudo-random 64-bit value. It is derived
+// from a fixed seed and must never change: chunk boundaries, and so object
+// ids, depend on it.
+var gearTable = func() (t [256]uint64) {
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range t {
+		// splitmix64
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		t[i] = z ^ z>>31
+	}
+	return t
+}()
+
+// Chunk is one entry of a chunk list.
+type Chunk struct {
+	Hash string
+	Size int64
+}
+
+// WriteChunked cuts the size bytes read from r into content-defined chunks
+// and stores each as a blob in s, followed by the chunk list, whose hash is
+// returned. With a nil store nothing is written and only the id the
+// content would get is computed, in the given format.
+//
+// At most one chunk is held in memory at a time.
+func WriteChunked(s ObjectStore, format ObjectFormat, size int64, r io.Reader) (string, error) {
+	br := bufio.NewReader(io.LimitReader(r, size))
+	buf := make([]byte, 0, maxChunkSize)
+	var list bytes.Buffer
+	var total int64
+
+	flush := func() error {
+		var hash string
+		if s != nil {
+			var err error
+			if hash, err = s.Write("blob", buf); err != nil {
+				return err
+			}
+		} else {
+			_, hash = PrepareObject(format, "blob", buf)
+		}
+		fmt.Fprintf(&list, "%s %d\n", hash, len(buf))
+		total += int64(len(buf))
+		buf = buf[:0]
+		return nil
+	}
+
+	var h uint64
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		buf = append(buf, b)
+		h = h<<1 + gearTable[b]
+
+		if len(buf) >= maxChunkSize || (len(buf) >= minChunkSize && h&(1<<chunkMaskBits-1) == 0) {
+			if err := flush(); err != nil {
+				return "", err
+			}
+			h = 0
+		}
+	}
+	if len(buf) > 0 || total == 0 {
+		if err := flush(); err != nil {
+			return "", err
+		}
+	}
+	if total != size {
+		return "", fmt.Errorf("short read: got %d of %d bytes", total, size)
+	}
+
+	if s != nil {
+		return s.Write(ChunksType, list.Bytes())
+	}
+	_, hash := PrepareObject(format, ChunksType, list.Bytes())
+	return hash, nil
+}
+
+// ParseChunks decodes the body of a chunk list.
+func ParseChunks(content []byte) ([]Chunk, error) {
+	var chunks []Chunk
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		hash, sizeStr, ok := strings.Cut(line, " ")
+		size, err := strconv.ParseInt(sizeStr, 10, 64)
+		if !ok || err != nil || size < 0 || !isHex(hash) {
+			return nil, fmt.Errorf("malformed chunk list line %q", line)
+		}
+		chunks = append(chunks, Chunk{Hash: hash, Size: size})
+	}
+	return chunks, nil
+}
+
+// OpenBlob streams a file's content whether it is stored as a blob or as a
+// chunk list, returning its total size. The caller must close the reader.
+func OpenBlob(s ObjectStore, hash string) (size int64, rc io.ReadCloser, err error) {
+	objType, size, rc, err := s.Open(hash)
+	if err != nil || objType == "blob" {
+		return size, rc, err
+	}
+	defer rc.Close()
+	if objType != ChunksType {
+		return 0, nil, fmt.Errorf("object %s is a %s, not a blob", hash, objType)
+	}
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return 0, nil, err
+	}
+	chunks, err := ParseChunks(content)
+	if err != nil {
+		return 0, nil, fmt.Errorf("chunk list %s: %w", hash, err)
+	}
+	size = 0
+	for _, c := range chunks {
+		if c.Size > math.MaxInt64-size {
+			return 0, nil, fmt.Errorf("chunk list %s: total size overflows", hash)
+		}
+		size += c.Size
+	}
+	return size, &chunkReader{store: s, chunks: chunks}, nil
+}
+
+// ReadBlob is OpenBlob for callers that want the whole content in memory.
+// A chunk list's size is only what its content claims, so nothing is
+// allocated up front: the buffer grows with what the chunks really hold.
+func ReadBlob(s ObjectStore, hash string) ([]byte, error) {
+	size, rc, err := OpenBlob(s, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, size+1))
+	if err != nil {
+		return nil, fmt.Errorf("blob %s: %w", hash, err)
+	}
+	if int64(len(content)) != size {
+		return nil, fmt.Errorf("blob %s: has %d bytes, want %d", hash, len(content), size)
+	}
+	return content, nil
+}
+
+// chunkReader concatenates the chunks of a chunk list, opening each one
+// only when the previous one is exhausted.
+type chunkReader struct {
+	store  ObjectStore
+	chunks []Chunk
+	cur    io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.cur == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+			next := c.chunks[0]
+			c.chunks = c.chunks[1:]
+			_, size, rc, err := c.store.Open(next.Hash)
+			if err != nil {
+				return 0, err
+			}
+			if size != next.Size {
+				rc.Close()
+				return 0, fmt.Errorf("chunk %s is %d bytes, list says %d", next.Hash, size, next.Size)
+			}
+			c.cur = rc
+		}
+
+		n, err := c.cur.Read(p)
+		if err == io.EOF {
+			c.cur.Close()
+			c.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.cur != nil {
+		return c.cur.Close()
+	}
+	return nil
+}
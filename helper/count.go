@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Object directory accounting
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ObjectCounts summarises what is on disk under objects/. Sizes are in
+// bytes as stored, i.e. compressed.
+type ObjectCounts struct {
+	Count         int      // loose objects
+	Size          int64    // bytes used by loose objects
+	InPack        int      // objects stored in packs
+	Packs         int      // number of packs
+	SizePack      int64    // bytes used by packs and their indexes
+	PrunePackable int      // loose objects that are also in a pack
+	Garbage       []string // files that are neither objects nor packs
+	SizeGarbage   int64    // bytes used by garbage files
+}
+
+// CountObjects walks objects/ and tallies loose objects, packs and any
+// stray files — typically temp files left by an interrupted write, or a
+// pack missing its index.
+func CountObjects(gitDir string) (*ObjectCounts, error) {
+	root := filepath.Join(gitDir, "objects")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	packed, err := PackedObjects(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	inPack := make(map[string]bool, len(packed))
+	for _, h := range packed {
+		inPack[h] = true
+	}
+
+	c := &ObjectCounts{InPack: len(inPack)}
+	garbage := func(path string, info os.FileInfo) {
+		c.Garbage = append(c.Garbage, path)
+		c.SizeGarbage += info.Size()
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(root, e.Name())
+		switch {
+		case e.IsDir() && isFanoutDir(e.Name()):
+			if err := countFanout(c, path, e.Name(), inPack, garbage); err != nil {
+				return nil, err
+			}
+		case e.IsDir():
+			// pack/ is handled below; info/ holds metadata, not objects.
+		default:
+			info, err := e.Info()
+			if err != nil {
+				return nil, err
+			}
+			garbage(path, info)
+		}
+	}
+
+	if err := countPacks(c, filepath.Join(root, packDir), garbage); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// countFanout tallies one objects/xx/ directory.
+func countFanout(c *ObjectCounts, dir, prefix string, inPack map[string]bool, garbage func(string, os.FileInfo)) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		if f.IsDir() || !isHex(f.Name()) {
+			garbage(filepath.Join(dir, f.Name()), info)
+			continue
+		}
+		c.Count++
+		c.Size += info.Size()
+		if inPack[prefix+f.Name()] {
+			c.PrunePackable++
+		}
+	}
+	return nil
+}
+
+// countPacks tallies objects/pack/. A .pack or .idx only counts when its
+// partner exists; anything else there is garbage.
+func countPacks(c *ObjectCounts, dir string, garbage func(string, os.FileInfo)) error {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		present[f.Name()] = true
+	}
+
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		name := f.Name()
+		switch {
+		case strings.HasSuffix(name, ".pack") && present[strings.TrimSuffix(name, ".pack")+".idx"]:
+			c.Packs++
+			c.SizePack += info.Size()
+		case strings.HasSuffix(name, ".idx") && present[strings.TrimSuffix(name, ".idx")+".pack"]:
+			c.SizePack += info.Size()
+		default:
+			garbage(filepath.Join(dir, name), info)
+		}
+	}
+	return nil
+}
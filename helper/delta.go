@@ -0,0 +1,194 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Delta encoding
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// A delta rebuilds a target object from a base object using git's
+// instruction stream:
+//
+//	baseSize varint | targetSize varint | ops...
+//
+//	copy:   1oooossss, then the present offset/size bytes (little-endian)
+//	insert: 0nnnnnnn, then n literal bytes (1 ≤ n ≤ 127)
+//
+// A copy size of zero means 0x10000, as in git.
+
+const (
+	deltaBlock   = 16       // bytes per indexed base block
+	maxCopySize  = 0xffffff // largest size a single copy op can encode
+	maxInsertLen = 0x7f     // largest literal run a single insert op can carry
+)
+
+// makeDelta returns a delta that turns base into target. Base is indexed in
+// fixed-size blocks; target is scanned byte by byte for block matches, which
+// are then extended as far as both sides agree.
+func makeDelta(base, target []byte) []byte {
+	var out bytes.Buffer
+	putVarint(&out, uint64(len(base)))
+	putVarint(&out, uint64(len(target)))
+
+	blocks := make(map[string]int, len(base)/deltaBlock)
+	for i := 0; i+deltaBlock <= len(base); i += deltaBlock {
+		key := string(base[i : i+deltaBlock])
+		if _, ok := blocks[key]; !ok {
+			blocks[key] = i
+		}
+	}
+
+	var pending []byte
+	flush := func() {
+		for len(pending) > 0 {
+			n := min(len(pending), maxInsertLen)
+			out.WriteByte(byte(n))
+			out.Write(pending[:n])
+			pending = pending[n:]
+		}
+	}
+
+	for i := 0; i < len(target); {
+		off, ok := -1, false
+		if i+deltaBlock <= len(target) {
+			off, ok = blocks[string(target[i:i+deltaBlock])]
+		}
+		if !ok {
+			pending = append(pending, target[i])
+			i++
+			continue
+		}
+
+		// Extend the match backwards into the pending literals, then forwards.
+		for off > 0 && len(pending) > 0 && base[off-1] == pending[len(pending)-1] {
+			off--
+			i--
+			pending = pending[:len(pending)-1]
+		}
+		n := 0
+		for off+n < len(base) && i+n < len(target) && base[off+n] == target[i+n] {
+			n++
+		}
+
+		flush()
+		for n > 0 {
+			size := min(n, maxCopySize)
+			putCopyOp(&out, off, size)
+			off += size
+			i += size
+			n -= size
+		}
+	}
+	flush()
+	return out.Bytes()
+}
+
+// applyDelta rebuilds the target object described by delta from base.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	r := bytes.NewReader(delta)
+	baseSize, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base size %d does not match %d", baseSize, len(base))
+	}
+	targetSize, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// targetSize comes from the delta itself, so it only sizes the buffer
+	// initially; copies from base still grow it as needed.
+	out := make([]byte, 0, min(targetSize, uint64(len(base)+len(delta))))
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+
+		if op&0x80 == 0 {
+			if op == 0 {
+				return nil, fmt.Errorf("invalid delta opcode 0")
+			}
+			lit := make([]byte, op)
+			if n, _ := r.Read(lit); n != int(op) {
+				return nil, fmt.Errorf("truncated delta insert")
+			}
+			out = append(out, lit...)
+			continue
+		}
+
+		var off, size uint64
+		for bit := 0; bit < 7; bit++ {
+			if op&(1<<bit) == 0 {
+				continue
+			}
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("truncated delta copy")
+			}
+			if bit < 4 {
+				off |= uint64(b) << (8 * bit)
+			} else {
+				size |= uint64(b) << (8 * (bit - 4))
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if off+size > uint64(len(base)) {
+			return nil, fmt.Errorf("delta copy out of range")
+		}
+		out = append(out, base[off:off+size]...)
+	}
+
+	if uint64(len(out)) != targetSize {
+		return nil, fmt.Errorf("delta produced %d bytes, want %d", len(out), targetSize)
+	}
+	return out, nil
+}
+
+// putCopyOp encodes a copy instruction, omitting zero offset/size bytes.
+func putCopyOp(out *bytes.Buffer, off, size int) {
+	op := byte(0x80)
+	var args []byte
+	for bit := 0; bit < 4; bit++ {
+		if b := byte(off >> (8 * bit)); b != 0 {
+			op |= 1 << bit
+			args = append(args, b)
+		}
+	}
+	for bit := 0; bit < 3; bit++ {
+		if b := byte(size >> (8 * bit)); b != 0 {
+			op |= 1 << (4 + bit)
+			args = append(args, b)
+		}
+	}
+	out.WriteByte(op)
+	out.Write(args)
+}
+
+// putVarint writes v as a little-endian base-128 varint.
+func putVarint(out *bytes.Buffer, v uint64) {
+	for v >= 0x80 {
+		out.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	out.WriteByte(byte(v))
+}
+
+// readVarint reads a little-endian base-128 varint.
+func readVarint(r *bytes.Reader) (uint64, error) {
+	var v uint64
+	for shift := 0; ; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("truncated delta header")
+		}
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+}
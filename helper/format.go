@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Object formats
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ObjectFormat selects the hash function used for object ids. SHA-256 is
+// gitingo's native format; SHA-1 produces objects git itself can read.
+type ObjectFormat string
+
+const (
+	SHA1   ObjectFormat = "sha1"
+	SHA256 ObjectFormat = "sha256"
+
+	DefaultObjectFormat = SHA256
+)
+
+// ParseObjectFormat validates a format name. An empty name means the
+// default, which is also what repositories created before the option
+// existed use.
+func ParseObjectFormat(name string) (ObjectFormat, error) {
+	switch ObjectFormat(name) {
+	case "":
+		return DefaultObjectFormat, nil
+	case SHA1, SHA256:
+		return ObjectFormat(name), nil
+	default:
+		return "", fmt.Errorf("unknown object format %q — want sha1 or sha256", name)
+	}
+}
+
+// New returns a fresh hash.Hash for the format.
+func (f ObjectFormat) New() hash.Hash {
+	if f == SHA1 {
+		return sha1.New()
+	}
+	return sha256.New()
+}
+
+// Size returns the length of a raw (binary) object id in bytes.
+func (f ObjectFormat) Size() int {
+	if f == SHA1 {
+		return sha1.Size
+	}
+	return sha256.Size
+}
+
+// HexSize returns the length of a hex object id.
+func (f ObjectFormat) HexSize() int { return 2 * f.Size() }
@@ -0,0 +1,322 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Reading git's own object store
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// Loose objects in a .git directory use the same zlib-on-disk layout as
+// ours, so readLoose works on them unchanged. Git's packs differ: a v2 idx
+// ("\377tOc") and offset deltas (type 6) that name their base by its
+// distance back in the pack rather than by hash.
+//
+//	idx v2: magic | version u32 | fanout 256×u32 | count × hash
+//	        count × crc32 | count × offset u32 | large offsets u64...
+//	        pack checksum | idx checksum
+//
+// An offset with the high bit set indexes the large-offset table instead.
+
+const gitIdxMagic = "\377tOc"
+
+// gitOfsDelta is the pack type code of an entry whose base is named by a
+// backwards offset within the same pack.
+const gitOfsDelta = 6
+
+// gitTag is git's pack type code for annotated tag objects.
+const gitTag = 4
+
+// gitBaseCacheSize bounds how many resolved delta bases GitStore keeps.
+const gitBaseCacheSize = 256
+
+// gitMaxDeltaChain is the longest delta chain GitStore will follow; git
+// itself never writes chains deeper than 4095.
+const gitMaxDeltaChain = 4095
+
+// GitStore reads objects out of a git repository's .git directory, loose
+// or packed. It is read-only: gitingo never writes into a git repository.
+type GitStore struct {
+	GitDir string
+	format ObjectFormat
+	packs  []*gitPack
+}
+
+// gitPack is one parsed git pack and its index.
+type gitPack struct {
+	path    string
+	offsets map[string]int64
+	cache   map[int64]gitObject // resolved entries, keyed by offset
+}
+
+// gitObject is a fully inflated, delta-resolved object.
+type gitObject struct {
+	objType string
+	content []byte
+}
+
+// OpenGitStore opens the object store of the git repository at gitDir
+// (the .git directory itself, or the root of a bare repository).
+func OpenGitStore(gitDir string) (*GitStore, error) {
+	if !IsDirectory(filepath.Join(gitDir, "objects")) {
+		return nil, fmt.Errorf("%s: not a git repository", gitDir)
+	}
+
+	s := &GitStore{GitDir: gitDir, format: gitObjectFormat(gitDir)}
+
+	dir := filepath.Join(gitDir, "objects", packDir)
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".idx") {
+			continue
+		}
+		pack, err := loadGitPackIndex(filepath.Join(dir, f.Name()), s.format.Size())
+		if err != nil {
+			return nil, err
+		}
+		s.packs = append(s.packs, pack)
+	}
+	return s, nil
+}
+
+// Format returns the hash function the git repository uses.
+func (s *GitStore) Format() ObjectFormat { return s.format }
+
+// Has reports whether hash is stored loose or in any pack.
+func (s *GitStore) Has(hash string) bool {
+	if _, err := os.Stat(objectPath(s.GitDir, hash)); err == nil {
+		return true
+	}
+	for _, pack := range s.packs {
+		if _, ok := pack.offsets[hash]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Read returns an object's type and content. Annotated tags are returned
+// as type "tag"; gitingo has no use for them beyond recognising them.
+func (s *GitStore) Read(hash string) (string, []byte, error) {
+	return s.readAt(hash, 0)
+}
+
+// readAt is Read for an object depth deltas down a chain.
+func (s *GitStore) readAt(hash string, depth int) (string, []byte, error) {
+	data, err := readLoose(s.GitDir, hash)
+	if err == nil {
+		return splitObject(data)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", nil, err
+	}
+
+	for _, pack := range s.packs {
+		if off, ok := pack.offsets[hash]; ok {
+			obj, err := s.readPackEntry(pack, off, depth)
+			if err != nil {
+				return "", nil, fmt.Errorf("git object %s: %w", hash, err)
+			}
+			return obj.objType, obj.content, nil
+		}
+	}
+	return "", nil, fmt.Errorf("object not found: %s", hash)
+}
+
+// readPackEntry inflates the entry at off and resolves it against its
+// delta base, if it has one. Chains longer than gitMaxDeltaChain, which
+// also catches ref-delta cycles, are rejected.
+func (s *GitStore) readPackEntry(pack *gitPack, off int64, depth int) (gitObject, error) {
+	if obj, ok := pack.cache[off]; ok {
+		return obj, nil
+	}
+
+	f, err := os.Open(pack.path)
+	if err != nil {
+		return gitObject{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(io.NewSectionReader(f, off, 1<<62))
+	code, size, err := readPackHeader(r)
+	if err != nil {
+		return gitObject{}, err
+	}
+
+	if (code == gitOfsDelta || code == packRefDelta) && depth >= gitMaxDeltaChain {
+		return gitObject{}, fmt.Errorf("delta chain at %d longer than %d", off, gitMaxDeltaChain)
+	}
+
+	var base *gitObject
+	switch code {
+	case gitOfsDelta:
+		back, err := readOfsDeltaOffset(r)
+		if err != nil {
+			return gitObject{}, err
+		}
+		if back <= 0 || back > off {
+			return gitObject{}, fmt.Errorf("bad delta base offset at %d", off)
+		}
+		obj, err := s.readPackEntry(pack, off-back, depth+1)
+		if err != nil {
+			return gitObject{}, err
+		}
+		base = &obj
+
+	case packRefDelta:
+		raw := make([]byte, s.format.Size())
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return gitObject{}, err
+		}
+		typ, content, err := s.readAt(hex.EncodeToString(raw), depth+1)
+		if err != nil {
+			return gitObject{}, err
+		}
+		base = &gitObject{typ, content}
+	}
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return gitObject{}, err
+	}
+	defer zr.Close()
+
+	content, err := inflateExact(zr, size)
+	if err != nil {
+		return gitObject{}, err
+	}
+
+	var obj gitObject
+	if base != nil {
+		if content, err = applyDelta(base.content, content); err != nil {
+			return gitObject{}, err
+		}
+		obj = gitObject{base.objType, content}
+	} else if code == gitTag {
+		obj = gitObject{"tag", content}
+	} else if typ := packTypeName(code); typ != "" && code != packChunks {
+		obj = gitObject{typ, content}
+	} else {
+		return gitObject{}, fmt.Errorf("unknown pack type %d at %d", code, off)
+	}
+
+	// Bases are re-read for every delta built on them; a small cache keeps
+	// long chains from being inflated over and over. It is simply reset
+	// when full.
+	if len(pack.cache) >= gitBaseCacheSize {
+		pack.cache = make(map[int64]gitObject)
+	}
+	pack.cache[off] = obj
+	return obj, nil
+}
+
+// readOfsDeltaOffset decodes git's offset-delta base distance. Unlike the
+// size varint, each continuation adds one before shifting so that no two
+// encodings name the same distance.
+func readOfsDeltaOffset(r io.ByteReader) (int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	off := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+		off = (off+1)<<7 | int64(b&0x7f)
+	}
+	return off, nil
+}
+
+// loadGitPackIndex parses a git .idx file, version 1 or 2.
+func loadGitPackIndex(path string, hashLen int) (*gitPack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	pack := &gitPack{
+		path:  strings.TrimSuffix(path, ".idx") + ".pack",
+		cache: make(map[int64]gitObject),
+	}
+
+	if bytes.HasPrefix(data, []byte(gitIdxMagic)) {
+		if len(data) < 8+256*4 {
+			return nil, fmt.Errorf("%s: truncated index", name)
+		}
+		if v := binary.BigEndian.Uint32(data[4:]); v != 2 {
+			return nil, fmt.Errorf("%s: unsupported git index version %d", name, v)
+		}
+		count := int(binary.BigEndian.Uint32(data[8+255*4:]))
+		hashes := 8 + 256*4
+		offsets := hashes + count*(hashLen+4)
+		large := offsets + count*4
+		if len(data) < large {
+			return nil, fmt.Errorf("%s: truncated index", name)
+		}
+
+		pack.offsets = make(map[string]int64, count)
+		for i := 0; i < count; i++ {
+			h := hex.EncodeToString(data[hashes+i*hashLen : hashes+(i+1)*hashLen])
+			off := int64(binary.BigEndian.Uint32(data[offsets+i*4:]))
+			if off&0x80000000 != 0 {
+				at := large + int(off&0x7fffffff)*8
+				if at+8 > len(data) {
+					return nil, fmt.Errorf("%s: truncated index", name)
+				}
+				off = int64(binary.BigEndian.Uint64(data[at:]))
+			}
+			pack.offsets[h] = off
+		}
+		return pack, nil
+	}
+
+	// Version 1: the fanout table, then (offset u32 | hash) records.
+	if len(data) < 256*4 {
+		return nil, fmt.Errorf("%s: not a pack index", name)
+	}
+	count := int(binary.BigEndian.Uint32(data[255*4:]))
+	rec := 4 + hashLen
+	if len(data) < 256*4+count*rec {
+		return nil, fmt.Errorf("%s: truncated index", name)
+	}
+	pack.offsets = make(map[string]int64, count)
+	for i := 0; i < count; i++ {
+		r := data[256*4+i*rec:]
+		pack.offsets[hex.EncodeToString(r[4:rec])] = int64(binary.BigEndian.Uint32(r))
+	}
+	return pack, nil
+}
+
+// gitObjectFormat reads extensions.objectformat from a git config,
+// defaulting to sha1 as git itself does.
+func gitObjectFormat(gitDir string) ObjectFormat {
+	data, err := os.ReadFile(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return SHA1
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "objectformat") {
+			if f, err := ParseObjectFormat(strings.TrimSpace(value)); err == nil {
+				return f
+			}
+		}
+	}
+	return SHA1
+}
@@ -0,0 +1,660 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Pack layout
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// A pack bundles many objects into objects/pack/pack-<sum>.pack, with a
+// sorted lookup table in the matching pack-<sum>.idx.
+//
+//	pack: "PACK" | version u32 | count u32 | entries... | sha256 of all prior bytes
+//	entry: type+size varint header | zlib(content)
+//	       or, for deltas: header | base hash | zlib(delta)
+//
+//	idx:  "GIDX" | version u32 | hashLen u32 | count u32
+//	      count × (hash [hashLen]byte | offset u64 | crc32 u32), sorted by hash
+//	      pack checksum [32]byte | sha256 of all prior idx bytes
+//
+// The entry header packs the type into bits 4-6 of the first byte and the
+// content size into the remaining bits, 7 bits per continuation byte —
+// the same scheme git uses.
+
+const (
+	packDir     = "pack"
+	packMagic   = "PACK"
+	idxMagic    = "GIDX"
+	packVersion = 1
+)
+
+// Pack entry type codes, matching git's numbering. A ref-delta entry holds
+// the raw hash of its base object followed by delta instructions. Chunk
+// lists take code 5, which git leaves unused.
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packChunks   = 5
+	packRefDelta = 7
+)
+
+// Delta search tuning.
+const (
+	packWindow    = 10       // earlier objects considered as delta bases
+	maxDeltaDepth = 10       // longest allowed chain of deltas-on-deltas
+	maxDeltaChain = 50       // longest chain a reader will follow
+	minDeltaSize  = 64       // objects smaller than this are always stored whole
+	maxDeltaSize  = 16 << 20 // larger objects are streamed whole, never deltified
+)
+
+var packTypes = map[string]byte{"commit": packCommit, "tree": packTree, "blob": packBlob, ChunksType: packChunks}
+
+// packEntry locates one object inside a pack file.
+type packEntry struct {
+	offset int64
+	crc    uint32
+}
+
+// packIndex is the parsed form of a .idx file.
+type packIndex struct {
+	packPath string
+	checksum []byte // trailer the pack file must end with
+	entries  map[string]packEntry
+}
+
+// Packs are immutable once written, so parsed indexes are cached by path
+// for the lifetime of the process.
+var (
+	packCacheMu sync.Mutex
+	packCache   = map[string]*packIndex{}
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Writing
+// ─────────────────────────────────────────────────────────────────────────────
+
+// PackObject names an object to pack. Path is an optional hint — the
+// repo-relative path a blob or tree was seen at — used to pick delta bases
+// among earlier versions of the same file.
+type PackObject struct {
+	Hash string
+	Path string
+}
+
+// packCandidate is an object queued for packing, along with its position
+// in any delta chain chosen for it. content is only loaded while the
+// object is in the delta window.
+type packCandidate struct {
+	PackObject
+	objType string
+	size    int64
+	content []byte
+	depth   int
+}
+
+// WritePack copies the given loose objects into a new pack and index under
+// objects/pack/ and returns the pack's checksum. The loose copies are left
+// in place; callers remove them once the pack is safely on disk.
+//
+// Objects are ordered by type, path and descending size, and each one is
+// tried as a delta against the previous packWindow objects of the same
+// type. A delta is kept only when it is much smaller than the object and
+// the resulting chain stays within maxDeltaDepth.
+//
+// Only the objects in the delta window are held in memory, each at most
+// maxDeltaSize bytes; everything else is streamed from disk, so memory use
+// does not grow with the size of the repository.
+func WritePack(gitDir string, objs []PackObject) (string, error) {
+	dir := filepath.Join(gitDir, "objects", packDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	seen := make(map[string]bool, len(objs))
+	cands := make([]*packCandidate, 0, len(objs))
+	for _, o := range objs {
+		if seen[o.Hash] {
+			continue
+		}
+		seen[o.Hash] = true
+
+		objType, size, rc, err := OpenObject(gitDir, o.Hash)
+		if err != nil {
+			return "", fmt.Errorf("pack %s: %w", o.Hash, err)
+		}
+		rc.Close()
+		if _, ok := packTypes[objType]; !ok {
+			return "", fmt.Errorf("pack %s: unknown object type %q", o.Hash, objType)
+		}
+		cands = append(cands, &packCandidate{PackObject: o, objType: objType, size: size})
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.objType != b.objType {
+			return a.objType < b.objType
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.size > b.size
+	})
+
+	tmp, err := os.CreateTemp(dir, "tmp-pack-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	sum := sha256.New()
+	w := &countingWriter{w: io.MultiWriter(tmp, sum)}
+
+	header := make([]byte, 12)
+	copy(header, packMagic)
+	binary.BigEndian.PutUint32(header[4:], packVersion)
+	binary.BigEndian.PutUint32(header[8:], uint32(len(cands)))
+	if _, err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	hashes := make([]string, 0, len(cands))
+	entries := make(map[string]packEntry, len(cands))
+	var window []*packCandidate
+	for _, c := range cands {
+		offset := w.n
+		crc := crc32.NewIEEE()
+		ew := io.MultiWriter(w, crc)
+
+		if c.size < minDeltaSize || c.size > maxDeltaSize {
+			err = streamPackEntry(ew, gitDir, c)
+		} else {
+			if _, c.content, err = readRaw(gitDir, c.Hash); err != nil {
+				return "", fmt.Errorf("pack %s: %w", c.Hash, err)
+			}
+			var entry []byte
+			if base, delta := pickDeltaBase(window, c); base != nil {
+				c.depth = base.depth + 1
+				entry, err = encodeDeltaEntry(base.Hash, delta)
+			} else {
+				entry, err = encodePackEntry(packTypes[c.objType], c.content)
+			}
+			if err == nil {
+				_, err = ew.Write(entry)
+			}
+
+			window = append(window, c)
+			if len(window) > packWindow {
+				window[0].content = nil
+				window = window[1:]
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		hashes = append(hashes, c.Hash)
+		entries[c.Hash] = packEntry{offset: offset, crc: crc.Sum32()}
+	}
+	sort.Strings(hashes)
+
+	checksum := sum.Sum(nil)
+	if _, err := tmp.Write(checksum); err != nil {
+		return "", err
+	}
+	if err := tmp.Chmod(0444); err != nil {
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	name := hex.EncodeToString(checksum)
+	base := filepath.Join(dir, "pack-"+name)
+	if err := writePackIndex(base+".idx", hashes, entries, checksum); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), base+".pack"); err != nil {
+		os.Remove(base + ".idx")
+		return "", err
+	}
+	return name, SyncDir(dir)
+}
+
+// streamPackEntry writes c as a whole (non-delta) entry, deflating it
+// straight from the object store without holding it in memory.
+func streamPackEntry(w io.Writer, gitDir string, c *packCandidate) error {
+	_, size, rc, err := OpenObject(gitDir, c.Hash)
+	if err != nil {
+		return fmt.Errorf("pack %s: %w", c.Hash, err)
+	}
+	defer rc.Close()
+
+	var hdr bytes.Buffer
+	putPackHeader(&hdr, packTypes[c.objType], uint64(size))
+	if _, err := w.Write(hdr.Bytes()); err != nil {
+		return err
+	}
+	zw := zlib.NewWriter(w)
+	if err := copyExact(zw, rc, size); err != nil {
+		return fmt.Errorf("pack %s: %w", c.Hash, err)
+	}
+	return zw.Close()
+}
+
+// pickDeltaBase returns the window entry giving the smallest acceptable
+// delta for c, or nil when c should be stored whole.
+func pickDeltaBase(window []*packCandidate, c *packCandidate) (*packCandidate, []byte) {
+	if len(c.content) < minDeltaSize {
+		return nil, nil
+	}
+
+	var best *packCandidate
+	var bestDelta []byte
+	for _, b := range window {
+		if b.objType != c.objType || b.depth >= maxDeltaDepth || b.Hash == c.Hash {
+			continue
+		}
+		delta := makeDelta(b.content, c.content)
+		if len(delta) >= len(c.content)/2 {
+			continue
+		}
+		if best == nil || len(delta) < len(bestDelta) {
+			best, bestDelta = b, delta
+		}
+	}
+	return best, bestDelta
+}
+
+// encodePackEntry returns the varint header followed by the deflated content.
+func encodePackEntry(code byte, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	putPackHeader(&buf, code, uint64(len(content)))
+	if err := deflateTo(&buf, content); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeDeltaEntry returns a ref-delta entry: the varint header, the raw
+// base hash, then the deflated delta instructions.
+func encodeDeltaEntry(baseHash string, delta []byte) ([]byte, error) {
+	raw, err := hex.DecodeString(baseHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	putPackHeader(&buf, packRefDelta, uint64(len(delta)))
+	buf.Write(raw)
+	if err := deflateTo(&buf, delta); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// putPackHeader writes the type+size varint that starts every pack entry.
+func putPackHeader(buf *bytes.Buffer, code byte, size uint64) {
+	b := code<<4 | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		buf.WriteByte(b | 0x80)
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	buf.WriteByte(b)
+}
+
+// deflateTo appends the zlib-compressed form of data to buf.
+func deflateTo(buf *bytes.Buffer, data []byte) error {
+	zw := zlib.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writePackIndex writes the sorted lookup table for a pack.
+func writePackIndex(path string, hashes []string, entries map[string]packEntry, packSum []byte) error {
+	var buf bytes.Buffer
+	buf.WriteString(idxMagic)
+
+	hashLen := 0
+	if len(hashes) > 0 {
+		hashLen = len(hashes[0]) / 2
+	}
+	for _, v := range []int{packVersion, hashLen, len(hashes)} {
+		binary.Write(&buf, binary.BigEndian, uint32(v))
+	}
+
+	for _, h := range hashes {
+		raw, err := hex.DecodeString(h)
+		if err != nil || len(raw) != hashLen {
+			return fmt.Errorf("invalid object hash %q", h)
+		}
+		e := entries[h]
+		buf.Write(raw)
+		binary.Write(&buf, binary.BigEndian, uint64(e.offset))
+		binary.Write(&buf, binary.BigEndian, e.crc)
+	}
+
+	buf.Write(packSum)
+	idxSum := sha256.Sum256(buf.Bytes())
+	buf.Write(idxSum[:])
+
+	return WriteFileAtomic(path, buf.Bytes(), 0444)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Reading
+// ─────────────────────────────────────────────────────────────────────────────
+
+// PackedObjects returns the hashes of every object stored in a pack.
+func PackedObjects(gitDir string) ([]string, error) {
+	idxs, err := loadPacks(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	for _, idx := range idxs {
+		for h := range idx.entries {
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes, nil
+}
+
+// findPacked returns the pack containing hash, if any.
+func findPacked(gitDir, hash string) (*packIndex, packEntry, bool) {
+	idxs, err := loadPacks(gitDir)
+	if err != nil {
+		return nil, packEntry{}, false
+	}
+	for _, idx := range idxs {
+		if e, ok := idx.entries[hash]; ok {
+			return idx, e, true
+		}
+	}
+	return nil, packEntry{}, false
+}
+
+// readPacked reads an object out of whichever pack holds it. depth counts
+// the deltas already being resolved above it; a chain longer than
+// maxDeltaChain, or one that loops back on itself, is rejected.
+func readPacked(gitDir, hash string, depth int) (objType string, content []byte, err error) {
+	idx, e, ok := findPacked(gitDir, hash)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+
+	f, err := os.Open(idx.packPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(io.NewSectionReader(f, e.offset, 1<<62))
+	code, size, err := readPackHeader(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("pack entry %s: %w", hash, err)
+	}
+
+	var baseHash string
+	if code == packRefDelta {
+		raw := make([]byte, len(hash)/2)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return "", nil, fmt.Errorf("pack entry %s: %w", hash, err)
+		}
+		baseHash = hex.EncodeToString(raw)
+	} else if objType = packTypeName(code); objType == "" {
+		return "", nil, fmt.Errorf("pack entry %s: unknown type %d", hash, code)
+	}
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return "", nil, fmt.Errorf("pack entry %s: %w", hash, err)
+	}
+	defer zr.Close()
+
+	if content, err = inflateExact(zr, size); err != nil {
+		return "", nil, fmt.Errorf("pack entry %s: %w", hash, err)
+	}
+	if baseHash == "" {
+		return objType, content, nil
+	}
+
+	// WritePack keeps chains within maxDeltaDepth, but a corrupt or foreign
+	// pack may not, and a cycle would otherwise recurse forever.
+	if depth >= maxDeltaChain {
+		return "", nil, fmt.Errorf("pack entry %s: delta chain longer than %d", hash, maxDeltaChain)
+	}
+	objType, base, err := readAt(gitDir, baseHash, depth+1)
+	if err != nil {
+		return "", nil, err // already names the entry that failed
+	}
+	content, err = applyDelta(base, content)
+	if err != nil {
+		return "", nil, fmt.Errorf("pack entry %s: %w", hash, err)
+	}
+	return objType, content, nil
+}
+
+// readPackHeader decodes the type+size varint at the start of a pack entry.
+func readPackHeader(r io.ByteReader) (code byte, size uint64, err error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	code = (b >> 4) & 0x07
+	size = uint64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, 0, err
+		}
+		size |= uint64(b&0x7f) << shift
+	}
+	return code, size, nil
+}
+
+// inflateExact reads an entry's inflated content, which must be exactly
+// size bytes. The buffer grows with the data actually inflated, so a corrupt
+// size in an entry header cannot force a huge allocation up front.
+func inflateExact(r io.Reader, size uint64) ([]byte, error) {
+	if size >= 1<<62 {
+		return nil, fmt.Errorf("entry size %d is implausible", size)
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, int64(size)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(n) != size {
+		return nil, fmt.Errorf("entry inflates to %d bytes, header says %d", n, size)
+	}
+	return buf.Bytes(), nil
+}
+
+// packTypeName maps a pack type code back to its object type name.
+func packTypeName(code byte) string {
+	for name, c := range packTypes {
+		if c == code {
+			return name
+		}
+	}
+	return ""
+}
+
+// VerifyPacks checks every pack against its index: the trailing checksum
+// must match the pack body, and each entry's bytes must match its recorded
+// CRC. Problems found are returned as a list; the error is reserved for
+// failures to read the packs at all.
+func VerifyPacks(gitDir string) ([]error, error) {
+	idxs, err := loadPacks(gitDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []error
+	for _, idx := range idxs {
+		name := filepath.Base(idx.packPath)
+		data, err := os.ReadFile(idx.packPath)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if len(data) < 12+sha256.Size || string(data[:4]) != packMagic {
+			problems = append(problems, fmt.Errorf("%s: not a pack file", name))
+			continue
+		}
+
+		end := len(data) - sha256.Size
+		sum := sha256.Sum256(data[:end])
+		if !bytes.Equal(sum[:], data[end:]) || !bytes.Equal(sum[:], idx.checksum) {
+			problems = append(problems, fmt.Errorf("%s: pack checksum mismatch", name))
+		}
+
+		// Entries are contiguous, so each one ends where the next begins.
+		hashes := make([]string, 0, len(idx.entries))
+		for h := range idx.entries {
+			hashes = append(hashes, h)
+		}
+		sort.Slice(hashes, func(i, j int) bool {
+			return idx.entries[hashes[i]].offset < idx.entries[hashes[j]].offset
+		})
+		for i, h := range hashes {
+			e := idx.entries[h]
+			next := int64(end)
+			if i+1 < len(hashes) {
+				next = idx.entries[hashes[i+1]].offset
+			}
+			if e.offset < 12 || next > int64(end) || e.offset >= next {
+				problems = append(problems, fmt.Errorf("%s: entry %s has a bad offset", name, h))
+				continue
+			}
+			if crc32.ChecksumIEEE(data[e.offset:next]) != e.crc {
+				problems = append(problems, fmt.Errorf("%s: entry %s fails its CRC check", name, h))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// packLists caches loadPacks per pack directory.
+var packLists = newStatCache[[]*packIndex]()
+
+// loadPacks returns the parsed index of every pack under objects/pack/.
+// The list is only rebuilt when the directory changes.
+func loadPacks(gitDir string) ([]*packIndex, error) {
+	dir := filepath.Join(gitDir, "objects", packDir)
+	return packLists.get(dir, func() ([]*packIndex, error) { return scanPacks(dir) })
+}
+
+// scanPacks loads the index of every .idx file in dir.
+func scanPacks(dir string) ([]*packIndex, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idxs []*packIndex
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".idx") {
+			continue
+		}
+		idx, err := loadPackIndex(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		idxs = append(idxs, idx)
+	}
+	return idxs, nil
+}
+
+// loadPackIndex parses and verifies a .idx file, consulting the cache first.
+func loadPackIndex(path string) (*packIndex, error) {
+	packCacheMu.Lock()
+	defer packCacheMu.Unlock()
+
+	if idx, ok := packCache[path]; ok {
+		return idx, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 16+2*sha256.Size || string(data[:4]) != idxMagic {
+		return nil, fmt.Errorf("%s: not a pack index", filepath.Base(path))
+	}
+
+	body, trailer := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if sum := sha256.Sum256(body); !bytes.Equal(sum[:], trailer) {
+		return nil, fmt.Errorf("%s: index checksum mismatch", filepath.Base(path))
+	}
+
+	if v := binary.BigEndian.Uint32(data[4:]); v != packVersion {
+		return nil, fmt.Errorf("%s: unsupported index version %d", filepath.Base(path), v)
+	}
+	hashLen := int(binary.BigEndian.Uint32(data[8:]))
+	count := int(binary.BigEndian.Uint32(data[12:]))
+
+	rec := hashLen + 12
+	table := body[16 : len(body)-sha256.Size]
+	if len(table) != count*rec {
+		return nil, fmt.Errorf("%s: truncated index", filepath.Base(path))
+	}
+
+	idx := &packIndex{
+		packPath: strings.TrimSuffix(path, ".idx") + ".pack",
+		checksum: body[len(body)-sha256.Size:],
+		entries:  make(map[string]packEntry, count),
+	}
+	for i := 0; i < count; i++ {
+		r := table[i*rec : (i+1)*rec]
+		idx.entries[hex.EncodeToString(r[:hashLen])] = packEntry{
+			offset: int64(binary.BigEndian.Uint64(r[hashLen:])),
+			crc:    binary.BigEndian.Uint32(r[hashLen+8:]),
+		}
+	}
+
+	packCache[path] = idx
+	return idx, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ─────────────────────────────────────────────────────────────────────────────
+
+// countingWriter tracks how many bytes have passed through it so pack
+// entries can record their offsets.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Abbreviated object ids
+// ─────────────────────────────────────────────────────────────────────────────
+
+// MinAbbrev is the shortest hash prefix ResolveHash will expand.
+const MinAbbrev = 4
+
+// prefixFinder is implemented by stores that can list hashes sharing a
+// prefix without a full Iterate.
+type prefixFinder interface {
+	FindPrefix(prefix string) ([]string, error)
+}
+
+// AmbiguousError is returned when a prefix matches more than one object.
+type AmbiguousError struct {
+	Prefix     string
+	Candidates []string // full hashes, sorted
+	Types      []string // object type of each candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "short object id %s is ambiguous; candidates are:", e.Prefix)
+	for i, h := range e.Candidates {
+		fmt.Fprintf(&b, "\n  %s %s", h, e.Types[i])
+	}
+	return b.String()
+}
+
+// ResolveHash expands a full or abbreviated hex object id to the single
+// stored object it names. Prefixes must be at least MinAbbrev characters.
+func ResolveHash(s ObjectStore, prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < MinAbbrev {
+		return "", fmt.Errorf("object id %q is too short (need at least %d characters)", prefix, MinAbbrev)
+	}
+	if !isHex(prefix) {
+		return "", fmt.Errorf("not a valid object name %s", prefix)
+	}
+	if s.Has(prefix) {
+		return prefix, nil // already a full hash
+	}
+
+	matches, err := findPrefix(s, prefix)
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("object not found: %s", prefix)
+	case 1:
+		return matches[0], nil
+	}
+
+	amb := &AmbiguousError{Prefix: prefix, Candidates: matches}
+	for _, h := range matches {
+		typ, _, err := s.Read(h)
+		if err != nil {
+			typ = "unknown"
+		}
+		amb.Types = append(amb.Types, typ)
+	}
+	return "", amb
+}
+
+// findPrefix returns the sorted, distinct hashes in s that start with prefix.
+func findPrefix(s ObjectStore, prefix string) ([]string, error) {
+	var matches []string
+	if f, ok := s.(prefixFinder); ok {
+		found, err := f.FindPrefix(prefix)
+		if err != nil {
+			return nil, err
+		}
+		matches = found
+	} else {
+		err := s.Iterate(func(h string) error {
+			if strings.HasPrefix(h, prefix) {
+				matches = append(matches, h)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(matches)
+	out := matches[:0]
+	for i, h := range matches {
+		if i == 0 || matches[i-1] != h {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// FindPrefix scans only the objects/<prefix[:2]>/ fan-out directory and the
+// pack indexes, rather than every loose object — here and in each alternate.
+func (s *LooseStore) FindPrefix(prefix string) ([]string, error) {
+	var matches []string
+	for _, dir := range append([]string{s.GitDir}, Alternates(s.GitDir)...) {
+		found, err := findPrefixIn(dir, prefix)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+	return matches, nil
+}
+
+// findPrefixIn is FindPrefix for a single objects directory.
+func findPrefixIn(gitDir, prefix string) ([]string, error) {
+	var matches []string
+
+	files, err := os.ReadDir(filepath.Join(gitDir, "objects", prefix[:2]))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, f := range files {
+		if h := prefix[:2] + f.Name(); !f.IsDir() && strings.HasPrefix(h, prefix) {
+			matches = append(matches, h)
+		}
+	}
+
+	idxs, err := loadPacks(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range idxs {
+		for h := range idx.entries {
+			if strings.HasPrefix(h, prefix) {
+				matches = append(matches, h)
+			}
+		}
+	}
+	return matches, nil
+}
+
+// isHex reports whether s consists only of lowercase hex digits.
+func isHex(s string) bool {
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,65 @@
+package helper
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Stat-validated caches
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// Every object lookup that misses loose storage consults the pack list and
+// the alternates, and history walks make thousands of lookups in a row.
+// Both are derived from a single file or directory, so the derived value
+// is kept until that path's modification time or size changes.
+//
+// A path modified within racyWindow of being read is not cached: a second
+// change in the same timestamp tick would leave its mtime unchanged.
+
+const racyWindow = 2 * time.Second
+
+// statCache maps a path to a value loaded from it.
+type statCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]statEntry[T]
+}
+
+type statEntry[T any] struct {
+	mtime time.Time
+	size  int64
+	value T
+}
+
+func newStatCache[T any]() *statCache[T] {
+	return &statCache[T]{entries: make(map[string]statEntry[T])}
+}
+
+// get returns the value cached for path while path is unchanged, calling
+// load otherwise. A path that cannot be stat'ed is never cached, so load
+// decides what its absence means.
+func (c *statCache[T]) get(path string, load func() (T, error)) (T, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && e.mtime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e.value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if time.Since(info.ModTime()) > racyWindow {
+		c.mu.Lock()
+		c.entries[path] = statEntry[T]{mtime: info.ModTime(), size: info.Size(), value: value}
+		c.mu.Unlock()
+	}
+	return value, nil
+}
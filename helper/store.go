@@ -0,0 +1,195 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Object store backends
+// ─────────────────────────────────────────────────────────────────────────────
+
+// ObjectStore is the storage backend every higher-level package reads and
+// writes objects through. Hashes are always computed over the uncompressed
+// "<type> <size>\x00<content>" form, so every backend agrees on ids.
+type ObjectStore interface {
+	// Has reports whether hash is stored.
+	Has(hash string) bool
+	// Read returns an object's type and content.
+	Read(hash string) (objType string, content []byte, err error)
+	// Write stores content as an object of objType and returns its hash.
+	// Writing an object that already exists is a no-op.
+	Write(objType string, content []byte) (string, error)
+	// Iterate calls fn once for every stored object, in hash order.
+	// A non-nil error from fn stops the iteration and is returned.
+	Iterate(fn func(hash string) error) error
+
+	// Open streams an object's content; the caller must close the reader.
+	Open(hash string) (objType string, size int64, r io.ReadCloser, err error)
+	// WriteStream stores exactly size bytes read from r as an object of
+	// objType and returns its hash, without buffering the whole content.
+	WriteStream(objType string, size int64, r io.Reader) (string, error)
+
+	// Format returns the hash function object ids are computed with.
+	Format() ObjectFormat
+}
+
+// Verify checks that hash exists in s and has the expected type.
+func Verify(s ObjectStore, hash, objType string) error {
+	if len(hash) < 6 {
+		return fmt.Errorf("hash too short")
+	}
+	typ, _, err := s.Read(hash)
+	if err != nil {
+		return fmt.Errorf("object not found: %s", hash)
+	}
+	if typ != objType {
+		return fmt.Errorf("object %s is not of type %s", hash, objType)
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Filesystem backend
+// ─────────────────────────────────────────────────────────────────────────────
+
+// LooseStore is the on-disk backend: loose objects under objects/xx/ plus
+// any packs under objects/pack/. Reads fall back to the alternates; Iterate
+// lists only the objects this repository stores itself.
+type LooseStore struct {
+	GitDir       string
+	ObjectFormat ObjectFormat
+}
+
+func NewLooseStore(gitDir string, format ObjectFormat) *LooseStore {
+	return &LooseStore{GitDir: gitDir, ObjectFormat: format}
+}
+
+func (s *LooseStore) Format() ObjectFormat { return s.ObjectFormat }
+
+func (s *LooseStore) Has(hash string) bool { return HasObject(s.GitDir, hash) }
+
+func (s *LooseStore) Read(hash string) (string, []byte, error) {
+	return readRaw(s.GitDir, hash)
+}
+
+func (s *LooseStore) Write(objType string, content []byte) (string, error) {
+	return WriteObject(s.GitDir, s.ObjectFormat, objType, content)
+}
+
+func (s *LooseStore) Open(hash string) (string, int64, io.ReadCloser, error) {
+	return OpenObject(s.GitDir, hash)
+}
+
+func (s *LooseStore) WriteStream(objType string, size int64, r io.Reader) (string, error) {
+	return WriteObjectStream(s.GitDir, s.ObjectFormat, objType, size, r)
+}
+
+func (s *LooseStore) Iterate(fn func(hash string) error) error {
+	loose, err := LooseObjects(s.GitDir)
+	if err != nil {
+		return err
+	}
+	packed, err := PackedObjects(s.GitDir)
+	if err != nil {
+		return err
+	}
+	return iterateSorted(append(loose, packed...), fn)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// In-memory backend
+// ─────────────────────────────────────────────────────────────────────────────
+
+// MemoryStore keeps objects in a map. It is safe for concurrent use and is
+// meant for embedding and for hermetic tests that should not touch disk.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	format  ObjectFormat
+	objects map[string]memObject
+}
+
+type memObject struct {
+	objType string
+	content []byte
+}
+
+func NewMemoryStore(format ObjectFormat) *MemoryStore {
+	return &MemoryStore{format: format, objects: make(map[string]memObject)}
+}
+
+func (s *MemoryStore) Format() ObjectFormat { return s.format }
+
+func (s *MemoryStore) Has(hash string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.objects[hash]
+	return ok
+}
+
+// Read returns a copy of the content, so callers cannot change the stored
+// object through it.
+func (s *MemoryStore) Read(hash string) (string, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	o, ok := s.objects[hash]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	return o.objType, append([]byte(nil), o.content...), nil
+}
+
+func (s *MemoryStore) Write(objType string, content []byte) (string, error) {
+	_, hash := PrepareObject(s.format, objType, content)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[hash]; !ok {
+		s.objects[hash] = memObject{objType: objType, content: append([]byte(nil), content...)}
+	}
+	return hash, nil
+}
+
+func (s *MemoryStore) Open(hash string) (string, int64, io.ReadCloser, error) {
+	objType, content, err := s.Read(hash)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return objType, int64(len(content)), io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// WriteStream buffers the content: a memory store holds it all anyway.
+func (s *MemoryStore) WriteStream(objType string, size int64, r io.Reader) (string, error) {
+	var buf bytes.Buffer
+	if err := copyExact(&buf, r, size); err != nil {
+		return "", err
+	}
+	return s.Write(objType, buf.Bytes())
+}
+
+func (s *MemoryStore) Iterate(fn func(hash string) error) error {
+	s.mu.RLock()
+	hashes := make([]string, 0, len(s.objects))
+	for h := range s.objects {
+		hashes = append(hashes, h)
+	}
+	s.mu.RUnlock()
+	return iterateSorted(hashes, fn)
+}
+
+// iterateSorted calls fn for each distinct hash in sorted order.
+func iterateSorted(hashes []string, fn func(hash string) error) error {
+	sort.Strings(hashes)
+	for i, h := range hashes {
+		if i > 0 && hashes[i-1] == h {
+			continue
+		}
+		if err := fn(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package helper
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"testing"
+)
+
+// TestObjectStoreContract runs the same checks against every backend, so
+// the in-memory store can stand in for the on-disk one.
+func TestObjectStoreContract(t *testing.T) {
+	backends := []struct {
+		name  string
+		store func(t *testing.T) ObjectStore
+	}{
+		{"memory", func(t *testing.T) ObjectStore { return NewMemoryStore(SHA256) }},
+		{"loose", func(t *testing.T) ObjectStore { return NewLooseStore(t.TempDir(), SHA256) }},
+	}
+	objects := []struct {
+		objType string
+		content string
+	}{
+		{"blob", "hello\n"},
+		{"blob", ""},
+		{"tree", "100644 a\x00"},
+		{"commit", "tree 0\n\nmessage\n"},
+	}
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			s := b.store(t)
+
+			var hashes []string
+			for _, o := range objects {
+				hash, err := s.Write(o.objType, []byte(o.content))
+				if err != nil {
+					t.Fatalf("Write(%s, %q): %v", o.objType, o.content, err)
+				}
+				if _, want := PrepareObject(SHA256, o.objType, []byte(o.content)); hash != want {
+					t.Errorf("Write(%s, %q) = %s, want %s", o.objType, o.content, hash, want)
+				}
+				again, err := s.Write(o.objType, []byte(o.content))
+				if err != nil || again != hash {
+					t.Errorf("rewrite of %s = %s, %v", hash, again, err)
+				}
+				hashes = append(hashes, hash)
+			}
+
+			for i, o := range objects {
+				hash := hashes[i]
+				if !s.Has(hash) {
+					t.Errorf("Has(%s) = false after Write", hash)
+				}
+				objType, content, err := s.Read(hash)
+				if err != nil {
+					t.Fatalf("Read(%s): %v", hash, err)
+				}
+				if objType != o.objType || string(content) != o.content {
+					t.Errorf("Read(%s) = %s %q, want %s %q", hash, objType, content, o.objType, o.content)
+				}
+
+				// The returned slice belongs to the caller.
+				if len(content) > 0 {
+					content[0] ^= 0xff
+					if _, again, _ := s.Read(hash); string(again) != o.content {
+						t.Errorf("Read(%s) after modifying a returned slice = %q", hash, again)
+					}
+				}
+
+				objType, size, rc, err := s.Open(hash)
+				if err != nil {
+					t.Fatalf("Open(%s): %v", hash, err)
+				}
+				streamed, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil || objType != o.objType || size != int64(len(o.content)) || string(streamed) != o.content {
+					t.Errorf("Open(%s) = %s %d %q, %v", hash, objType, size, streamed, err)
+				}
+			}
+
+			_, absent := PrepareObject(SHA256, "blob", []byte("never written"))
+			if s.Has(absent) {
+				t.Errorf("Has(%s) = true for an object never written", absent)
+			}
+			if _, _, err := s.Read(absent); !errors.Is(err, ErrObjectNotFound) {
+				t.Errorf("Read of a missing object: got %v, want ErrObjectNotFound", err)
+			}
+
+			var iterated []string
+			if err := s.Iterate(func(hash string) error {
+				iterated = append(iterated, hash)
+				return nil
+			}); err != nil {
+				t.Fatalf("Iterate: %v", err)
+			}
+			want := append([]string(nil), hashes...)
+			sort.Strings(want)
+			if len(iterated) != len(want) {
+				t.Fatalf("Iterate visited %d objects, want %d", len(iterated), len(want))
+			}
+			for i := range want {
+				if iterated[i] != want[i] {
+					t.Errorf("Iterate[%d] = %s, want %s", i, iterated[i], want[i])
+				}
+			}
+
+			stop := errors.New("stop")
+			calls := 0
+			if err := s.Iterate(func(string) error { calls++; return stop }); err != stop || calls != 1 {
+				t.Errorf("Iterate returning an error: got %v after %d calls", err, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Streaming object I/O
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// These mirror WriteObject/readRaw for content too large to hold in
+// memory. Memory use is bounded by the zlib and bufio buffers regardless
+// of object size — except for packed deltas, which must be rebuilt whole.
+
+// HashStream computes the object id of size bytes read from r without
+// storing anything.
+func HashStream(format ObjectFormat, objType string, size int64, r io.Reader) (string, error) {
+	h := format.New()
+	fmt.Fprintf(h, "%s %d\x00", objType, size)
+	if err := copyExact(h, r, size); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteObjectStream stores size bytes read from r as a loose object and
+// returns its hash. The content is deflated into a temporary file while it
+// is hashed, then renamed into place once the hash is known.
+func WriteObjectStream(gitDir string, format ObjectFormat, objType string, size int64, r io.Reader) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Join(gitDir, "objects"), "tmp-obj-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := format.New()
+	zw := zlib.NewWriter(tmp)
+	w := io.MultiWriter(h, zw)
+
+	fmt.Fprintf(w, "%s %d\x00", objType, size)
+	if err := copyExact(w, r, size); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	if err := tmp.Chmod(0444); err != nil {
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	if freshenLoose(gitDir, hash) || HasObject(gitDir, hash) {
+		return hash, nil // already stored
+	}
+
+	objPath := objectPath(gitDir, hash)
+	if err := os.MkdirAll(filepath.Dir(objPath), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), objPath); err != nil {
+		return "", err
+	}
+	return hash, SyncDir(filepath.Dir(objPath))
+}
+
+// OpenObject returns a reader over an object's content along with its type
+// and size. Loose objects and whole packed entries are inflated on the fly.
+// Objects missing locally are looked for in the alternates.
+// The caller must close the reader.
+func OpenObject(gitDir, hash string) (objType string, size int64, rc io.ReadCloser, err error) {
+	if len(hash) < 3 {
+		return "", 0, nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	objType, size, rc, err = openLocal(gitDir, hash)
+	if !errors.Is(err, ErrObjectNotFound) {
+		return objType, size, rc, err
+	}
+	for _, alt := range Alternates(gitDir) {
+		objType, size, rc, err = openLocal(alt, hash)
+		if !errors.Is(err, ErrObjectNotFound) {
+			return objType, size, rc, err
+		}
+	}
+	return "", 0, nil, err
+}
+
+// openLocal is OpenObject without the alternates.
+func openLocal(gitDir, hash string) (objType string, size int64, rc io.ReadCloser, err error) {
+	f, err := os.Open(objectPath(gitDir, hash))
+	if os.IsNotExist(err) {
+		return openPacked(gitDir, hash)
+	}
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	closers := []io.Closer{f}
+	var src io.Reader = bufio.NewReader(f)
+	if peek, _ := src.(*bufio.Reader).Peek(2); isZlib(peek) {
+		zr, err := zlib.NewReader(src)
+		if err != nil {
+			f.Close()
+			return "", 0, nil, err
+		}
+		closers = append(closers, zr)
+		src = bufio.NewReader(zr)
+	}
+
+	br := src.(*bufio.Reader)
+	header, err := br.ReadString(0)
+	if err != nil {
+		closeAll(closers)
+		return "", 0, nil, fmt.Errorf("malformed object header")
+	}
+	objType, sizeStr, _ := strings.Cut(strings.TrimSuffix(header, "\x00"), " ")
+	size, err = strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil {
+		closeAll(closers)
+		return "", 0, nil, fmt.Errorf("malformed object header")
+	}
+	return objType, size, &multiCloser{Reader: br, closers: closers}, nil
+}
+
+// openPacked streams a whole packed entry straight from the pack file.
+// Deltas fall back to an in-memory rebuild.
+func openPacked(gitDir, hash string) (string, int64, io.ReadCloser, error) {
+	idx, e, ok := findPacked(gitDir, hash)
+	if !ok {
+		return "", 0, nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+
+	f, err := os.Open(idx.packPath)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	r := bufio.NewReader(io.NewSectionReader(f, e.offset, 1<<62))
+	code, size, err := readPackHeader(r)
+	if err == nil && size >= 1<<62 {
+		err = fmt.Errorf("entry size %d is implausible", size)
+	}
+	if err != nil {
+		f.Close()
+		return "", 0, nil, fmt.Errorf("pack entry %s: %w", hash, err)
+	}
+
+	if code == packRefDelta {
+		f.Close()
+		objType, content, err := readPacked(gitDir, hash, 0)
+		if err != nil {
+			return "", 0, nil, err
+		}
+		return objType, int64(len(content)), io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	objType := packTypeName(code)
+	if objType == "" {
+		f.Close()
+		return "", 0, nil, fmt.Errorf("pack entry %s: unknown type %d", hash, code)
+	}
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		f.Close()
+		return "", 0, nil, fmt.Errorf("pack entry %s: %w", hash, err)
+	}
+	rc := &multiCloser{Reader: io.LimitReader(zr, int64(size)), closers: []io.Closer{zr, f}}
+	return objType, int64(size), rc, nil
+}
+
+// OpenFileContent opens a working-tree file for streaming and returns its
+// git mode and size. Symlinks yield their target path as content.
+func OpenFileContent(path string) (mode string, size int64, rc io.ReadCloser, err error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	mode = GitMode(info)
+	if mode == "120000" {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", 0, nil, err
+		}
+		return mode, int64(len(target)), io.NopCloser(strings.NewReader(target)), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return mode, info.Size(), f, nil
+}
+
+// copyExact copies r to w and fails unless exactly size bytes were read —
+// a file changing size mid-read must not produce a mislabelled object.
+func copyExact(w io.Writer, r io.Reader, size int64) error {
+	n, err := io.Copy(w, io.LimitReader(r, size+1))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("content size changed while reading: expected %d bytes, got %d", size, n)
+	}
+	return nil
+}
+
+// multiCloser closes every underlying resource of a layered reader.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error { return closeAll(m.closers) }
+
+// closeAll closes cs in reverse order and returns the first error.
+func closeAll(cs []io.Closer) error {
+	var first error
+	for i := len(cs) - 1; i >= 0; i-- {
+		if err := cs[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -5,70 +5,225 @@ package helper
 
 import (
 	"bytes"
-	"crypto/sha256"
+	"compress/zlib"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Object store
 // ─────────────────────────────────────────────────────────────────────────────
 
+// ErrObjectNotFound is wrapped by every error reporting that an object is
+// absent, as opposed to present but unreadable.
+var ErrObjectNotFound = errors.New("object not found")
+
 // PrepareObject wraps content in a git-style header and returns the
-// full byte slice and its SHA-256 hex hash.
+// full byte slice and its hex hash under the given object format.
 //
 // Format: "<type> <size>\x00<content>"
-func PrepareObject(objType string, content []byte) (full []byte, hash string) {
+func PrepareObject(format ObjectFormat, objType string, content []byte) (full []byte, hash string) {
 	header := fmt.Sprintf("%s %d\x00", objType, len(content))
 	full = append([]byte(header), content...)
-	sum := sha256.Sum256(full)
-	return full, hex.EncodeToString(sum[:])
+	h := format.New()
+	h.Write(full)
+	return full, hex.EncodeToString(h.Sum(nil))
 }
 
 // WriteObject writes content to objects/<hash[:2]>/<hash[2:]> and returns
-// the hash. Silently deduplicates: if the object already exists it is
-// not rewritten.
-func WriteObject(gitDir, objType string, content []byte) string {
-	full, hash := PrepareObject(objType, content)
+// the hash. The object is zlib-deflated on disk; the hash is always taken
+// over the uncompressed bytes. Silently deduplicates: if the object already
+// exists, loose or packed, it is not rewritten; a loose copy is freshened.
+//
+// The file is written atomically, so a crash never leaves a truncated
+// object behind under its final name.
+func WriteObject(gitDir string, format ObjectFormat, objType string, content []byte) (string, error) {
+	full, hash := PrepareObject(format, objType, content)
+	if freshenLoose(gitDir, hash) || HasObject(gitDir, hash) {
+		return hash, nil // already stored
+	}
+
+	var buf bytes.Buffer
+	if err := deflateTo(&buf, full); err != nil {
+		return "", err
+	}
 
 	objPath := objectPath(gitDir, hash)
-	if _, err := os.Stat(objPath); err == nil {
-		return hash // already stored
+	if err := os.MkdirAll(filepath.Dir(objPath), 0755); err != nil {
+		return "", err
+	}
+	if err := WriteFileAtomic(objPath, buf.Bytes(), 0444); err != nil {
+		return "", fmt.Errorf("write object %s: %w", hash, err)
 	}
+	return hash, nil
+}
 
-	_ = os.MkdirAll(filepath.Dir(objPath), 0755)
-	_ = os.WriteFile(objPath, full, 0644)
-	return hash
+// freshenLoose sets the mtime of hash's loose file to now and reports
+// whether there was one. An unreachable object that is about to be
+// referenced again must not look old enough for prune to delete it, as
+// git's freshen_loose_object ensures.
+func freshenLoose(gitDir, hash string) bool {
+	now := time.Now()
+	return os.Chtimes(objectPath(gitDir, hash), now, now) == nil
 }
 
-// ReadObject reads an object by hash and returns the content after the
-// null-byte header separator. Returns (nil, false) if the object is missing.
-func ReadObject(gitDir, hash string) ([]byte, bool) {
-	data, err := os.ReadFile(objectPath(gitDir, hash))
+// HasObject reports whether hash is stored loose or in any pack, here or
+// in an alternate.
+func HasObject(gitDir, hash string) bool {
+	if len(hash) < 3 {
+		return false
+	}
+	if hasLocal(gitDir, hash) {
+		return true
+	}
+	for _, alt := range Alternates(gitDir) {
+		if hasLocal(alt, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLocal is HasObject without the alternates.
+func hasLocal(gitDir, hash string) bool {
+	if _, err := os.Stat(objectPath(gitDir, hash)); err == nil {
+		return true
+	}
+	_, _, ok := findPacked(gitDir, hash)
+	return ok
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Loose objects
+// ─────────────────────────────────────────────────────────────────────────────
+
+// LooseObjects returns the hashes of every loose object under objects/,
+// skipping the pack/ and info/ subdirectories.
+func LooseObjects(gitDir string) ([]string, error) {
+	root := filepath.Join(gitDir, "objects")
+	dirs, err := os.ReadDir(root)
 	if err != nil {
-		return nil, false
+		return nil, err
+	}
+
+	var hashes []string
+	for _, d := range dirs {
+		if !d.IsDir() || !isFanoutDir(d.Name()) {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(root, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			// Skip in-flight temp files left by WriteFileAtomic.
+			if !f.IsDir() && isHex(f.Name()) {
+				hashes = append(hashes, d.Name()+f.Name())
+			}
+		}
+	}
+	return hashes, nil
+}
+
+// StatLoose returns the file info of a loose object, e.g. to read its
+// age or its size on disk.
+func StatLoose(gitDir, hash string) (os.FileInfo, error) {
+	return os.Stat(objectPath(gitDir, hash))
+}
+
+// RemoveLoose deletes a loose object file, and its fan-out directory
+// once that becomes empty.
+func RemoveLoose(gitDir, hash string) error {
+	path := objectPath(gitDir, hash)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	_ = os.Remove(filepath.Dir(path)) // fails harmlessly while non-empty
+	return nil
+}
+
+// readRaw looks hash up in loose storage first, then in packs, then in
+// each alternate, and returns its type and content.
+func readRaw(gitDir, hash string) (objType string, content []byte, err error) {
+	return readAt(gitDir, hash, 0)
+}
+
+// readAt is readRaw for an object depth deltas down a chain.
+func readAt(gitDir, hash string, depth int) (objType string, content []byte, err error) {
+	if len(hash) < 3 {
+		return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, hash)
+	}
+	objType, content, err = readLocal(gitDir, hash, depth)
+	if !errors.Is(err, ErrObjectNotFound) {
+		return objType, content, err
+	}
+	for _, alt := range Alternates(gitDir) {
+		objType, content, err = readLocal(alt, hash, depth)
+		if !errors.Is(err, ErrObjectNotFound) {
+			return objType, content, err
+		}
 	}
-	_, content, ok := bytes.Cut(data, []byte{0})
-	return content, ok
+	return "", nil, err
 }
 
-// VerifyObject checks that an object exists and starts with the expected
-// type prefix. Returns an error if not found or type mismatches.
-func VerifyObject(gitDir, hash, objType string) error {
-	if len(hash) < 6 {
-		return fmt.Errorf("hash too short")
+// readLocal is readAt without the alternates.
+func readLocal(gitDir, hash string, depth int) (objType string, content []byte, err error) {
+	data, err := readLoose(gitDir, hash)
+	if err == nil {
+		return splitObject(data)
 	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", nil, err
+	}
+	return readPacked(gitDir, hash, depth)
+}
+
+// splitObject separates "<type> <size>\x00<content>" into type and content.
+func splitObject(data []byte) (objType string, content []byte, err error) {
+	header, content, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("malformed object header")
+	}
+	objType, _, _ = strings.Cut(string(header), " ")
+	return objType, content, nil
+}
+
+// readLoose returns the full "<type> <size>\x00<content>" bytes of a loose
+// object, inflating it if needed. Objects written before compression was
+// introduced are stored raw and returned unchanged.
+func readLoose(gitDir, hash string) ([]byte, error) {
 	data, err := os.ReadFile(objectPath(gitDir, hash))
 	if err != nil {
-		return fmt.Errorf("object not found: %s", hash)
+		return nil, err
 	}
-	if !strings.HasPrefix(string(data), objType) {
-		return fmt.Errorf("object %s is not of type %s", hash, objType)
+	if !isZlib(data) {
+		return data, nil
 	}
-	return nil
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// isZlib reports whether data begins with a valid zlib stream header.
+// Raw objects always start with an ASCII type name ("blob", "tree",
+// "commit"), none of which can satisfy the CMF/FLG checksum below.
+func isZlib(data []byte) bool {
+	if len(data) < 2 {
+		return false
+	}
+	cmf, flg := data[0], data[1]
+	return cmf&0x0f == 8 && (uint16(cmf)<<8|uint16(flg))%31 == 0
 }
 
 // objectPath returns the filesystem path for an object hash.
@@ -76,33 +231,60 @@ func objectPath(gitDir, hash string) string {
 	return filepath.Join(gitDir, "objects", hash[:2], hash[2:])
 }
 
+// isFanoutDir reports whether name is a two-character hex object directory.
+func isFanoutDir(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // File helpers
 // ─────────────────────────────────────────────────────────────────────────────
 
-// ReadFileContent reads a file and returns its git mode, raw content, and
-// whether the read succeeded. Symlinks are read as their target path.
-func ReadFileContent(path string) (mode string, content []byte, ok bool) {
-	info, err := os.Lstat(path)
+// WriteFileAtomic replaces path with data so that readers — and a crash at
+// any point — see either the old content or the new, never a truncated mix.
+// The data goes to a temp file in the same directory, is fsynced, and is
+// then renamed over path.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		return "", nil, false
+		return err
 	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
 
-	mode = gitMode(info)
-
-	if mode == "120000" {
-		target, err := os.Readlink(path)
-		if err != nil {
-			return "", nil, false
-		}
-		return mode, []byte(target), true
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
 	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return SyncDir(dir)
+}
 
-	data, err := os.ReadFile(path)
+// SyncDir fsyncs a directory so that a preceding rename is durable.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
 	if err != nil {
-		return "", nil, false
+		return err
 	}
-	return mode, data, true
+	defer d.Close()
+	return d.Sync()
 }
 
 // IsDirectory reports whether path exists and is a directory.
@@ -111,8 +293,8 @@ func IsDirectory(path string) bool {
 	return err == nil && info.IsDir()
 }
 
-// gitMode maps an os.FileInfo to a git file mode string.
-func gitMode(info os.FileInfo) string {
+// GitMode maps an os.FileInfo to a git file mode string.
+func GitMode(info os.FileInfo) string {
 	switch {
 	case info.Mode()&os.ModeSymlink != 0:
 		return "120000"
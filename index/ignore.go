@@ -0,0 +1,216 @@
+package index
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Ignore rules
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// Untracked files are ignored according to .gitingoignore files, which may
+// appear in any directory, and .gitingo/info/exclude, with gitignore
+// semantics:
+//
+//	*.log        a name anywhere below the file's directory
+//	/build       anchored: only build directly in the file's directory
+//	docs/*.html  a pattern with a slash is always anchored
+//	tmp/         directories only
+//	**/cache     cache in any directory; a/**/b spans zero or more dirs
+//	!keep.log    negation: re-includes a path an earlier pattern ignored
+//
+// Patterns in deeper files take precedence over shallower ones, and the
+// exclude file over nothing; within a file the last matching pattern wins.
+// Everything inside an ignored directory is ignored and cannot be
+// re-included. Ignore rules never apply to files already in the index.
+
+const (
+	ignoreFile  = ".gitingoignore"
+	excludeFile = "exclude"
+)
+
+// ignorePattern is one compiled line of an ignore file.
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreList is the patterns of one ignore file, and the repo-relative
+// directory they are relative to ("" for the root).
+type ignoreList struct {
+	base     string
+	patterns []ignorePattern
+}
+
+// Ignore answers whether repo-relative paths are ignored. Per-directory
+// ignore files are read lazily and cached.
+type Ignore struct {
+	workDir string
+	exclude ignoreList
+	dirs    map[string]ignoreList
+}
+
+// LoadIgnore reads .gitingo/info/exclude; .gitingoignore files are read as
+// Match reaches their directories.
+func LoadIgnore(repo *repository.Repository) *Ignore {
+	return &Ignore{
+		workDir: repo.WorkDir,
+		exclude: readIgnoreFile(filepath.Join(repo.GitDir, "info", excludeFile), ""),
+		dirs:    make(map[string]ignoreList),
+	}
+}
+
+// Match reports whether the slash-separated, repo-relative path is
+// ignored, either itself or because a directory containing it is.
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if ig.matchOne(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return ig.matchOne(rel, isDir)
+}
+
+// matchOne applies every ignore file that covers rel, shallowest first, so
+// the last match found is the one with the highest precedence.
+func (ig *Ignore) matchOne(rel string, isDir bool) bool {
+	ignored := false
+	apply := func(list ignoreList) {
+		sub := rel
+		if list.base != "" {
+			sub = strings.TrimPrefix(rel, list.base+"/")
+		}
+		for _, p := range list.patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.re.MatchString(sub) {
+				ignored = !p.negate
+			}
+		}
+	}
+
+	apply(ig.exclude)
+	dir := ""
+	apply(ig.list(dir))
+	for _, part := range strings.Split(path.Dir(rel), "/") {
+		if part == "." {
+			break
+		}
+		dir = path.Join(dir, part)
+		apply(ig.list(dir))
+	}
+	return ignored
+}
+
+// list returns the patterns of dir's .gitingoignore, reading it once.
+func (ig *Ignore) list(dir string) ignoreList {
+	if l, ok := ig.dirs[dir]; ok {
+		return l
+	}
+	l := readIgnoreFile(filepath.Join(ig.workDir, filepath.FromSlash(dir), ignoreFile), dir)
+	ig.dirs[dir] = l
+	return l
+}
+
+// readIgnoreFile parses an ignore file; a missing file has no patterns.
+func readIgnoreFile(file, base string) ignoreList {
+	list := ignoreList{base: base}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return list
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if p, ok := parseIgnoreLine(strings.TrimSuffix(line, "\r")); ok {
+			list.patterns = append(list.patterns, p)
+		}
+	}
+	return list
+}
+
+// parseIgnoreLine compiles one line. Blank lines and comments yield false.
+func parseIgnoreLine(line string) (ignorePattern, bool) {
+	// Trailing spaces are dropped unless escaped.
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	var p ignorePattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to its directory;
+	// otherwise it matches a name at any depth.
+	prefix := "^(?:.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+	re, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+// globToRegexp translates a gitignore glob into a regular expression.
+// Wildcards never match "/", except in the "**" forms.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
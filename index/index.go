@@ -1,13 +1,20 @@
-// Package index manages the staging area — the flat file at .gitingo/index
-// that tracks the next set of changes to be committed.
+// Package index manages the staging area — the file at .gitingo/index that
+// tracks the next set of changes to be committed.
 package index
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/kasodeep/gitingo/helper"
@@ -21,15 +28,33 @@ const indexFile = "index"
 // ─────────────────────────────────────────────────────────────────────────────
 
 // IndexEntry is one tracked file: its git mode, blob hash, and repo-relative path.
+// Stat is the file's stat data when Hash was last computed from it; it is
+// zero for entries that came from a tree and were never hashed from disk.
 type IndexEntry struct {
 	Mode string
 	Hash string
 	Path string
+	Stat Stat
+}
+
+// Stat is the subset of a file's stat data the index caches. While it is
+// unchanged the file is assumed to still hash to its entry's Hash.
+type Stat struct {
+	CTime int64 // nanoseconds
+	MTime int64 // nanoseconds
+	Size  int64
+	Ino   uint64
+	Mode  uint32 // os.FileMode bits
 }
 
 // Index is a flat map of repo-relative paths to their staged entries.
 type Index struct {
 	Entries map[string]IndexEntry
+
+	// cached holds the entries whose stat data may stand in for rehashing,
+	// and modTime the index file's mtime when they were loaded.
+	cached  map[string]IndexEntry
+	modTime int64
 }
 
 func NewIndex() *Index {
@@ -44,27 +69,71 @@ func NewIndex() *Index {
 // A missing index file is treated as an empty index (first-ever add).
 func LoadIndex(repo *repository.Repository) (*Index, error) {
 	idx := NewIndex()
-	return idx, idx.parse(repo)
+	if err := idx.parse(repo); err != nil {
+		return nil, err
+	}
+	idx.cached = idx.Entries
+	return idx, nil
 }
 
 // LoadWorkingDirIndex walks the working directory and builds an index
 // from current on-disk files without writing any blobs to the object store.
 // Used for comparing staged vs unstaged state.
-func LoadWorkingDirIndex(repo *repository.Repository) *Index {
+//
+// Files whose stat data matches their staged entry are not rehashed; the
+// staged hash is reused.
+func LoadWorkingDirIndex(repo *repository.Repository) (*Index, error) {
+	staged, err := LoadIndex(repo)
+	if err != nil {
+		return nil, err
+	}
 	idx := NewIndex()
-	idx.AddFromPath(repo, repo.WorkDir, false)
-	return idx
+	idx.cached, idx.modTime = staged.Entries, staged.modTime
+	return idx, idx.AddFromPath(repo, repo.WorkDir, false)
 }
 
-// parse reads each "mode hash path" line from the index file into idx.Entries.
+// ─────────────────────────────────────────────────────────────────────────────
+// On-disk format
+// ─────────────────────────────────────────────────────────────────────────────
+//
+//	header:  "GNDX" | version u32 | hash size u32 | count u32
+//	entry:   ctime i64 | mtime i64 | size i64 | ino u64 | file mode u32
+//	         git mode u32 | hash | path length u16 | path
+//	trailer: checksum of everything above, in the repository's object format
+//
+// Entries are sorted by path. Older repositories have a text index of
+// "mode hash path" lines; it is still read, and replaced on the next write.
+
+const (
+	indexMagic   = "GNDX"
+	indexVersion = 2
+)
+
+// parse reads the index file into idx.Entries, in either format.
 func (idx *Index) parse(repo *repository.Repository) error {
-	f, err := os.Open(filepath.Join(repo.GitDir, indexFile))
+	path := filepath.Join(repo.GitDir, indexFile)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil // missing index == empty staging area
 	}
-	defer f.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	idx.modTime = info.ModTime().UnixNano()
 
-	scanner := bufio.NewScanner(f)
+	if bytes.HasPrefix(data, []byte(indexMagic)) {
+		if err := idx.decode(data, repo.Objects.Format()); err != nil {
+			return fmt.Errorf("index: %w", err)
+		}
+		return nil
+	}
+	return idx.parseText(data)
+}
+
+// parseText reads each "mode hash path" line of a legacy text index.
+func (idx *Index) parseText(data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		parts := strings.SplitN(scanner.Text(), " ", 3)
 		if len(parts) != 3 {
@@ -75,33 +144,172 @@ func (idx *Index) parse(repo *repository.Repository) error {
 	return scanner.Err()
 }
 
-// ─────────────────────────────────────────────────────────────────────────────
-// Write
-// ─────────────────────────────────────────────────────────────────────────────
+// decode parses a binary index, verifying its checksum first.
+func (idx *Index) decode(data []byte, format helper.ObjectFormat) error {
+	sumLen := format.Size()
+	if len(data) < 16+sumLen {
+		return fmt.Errorf("file truncated")
+	}
+	body, sum := data[:len(data)-sumLen], data[len(data)-sumLen:]
+	h := format.New()
+	h.Write(body)
+	if !bytes.Equal(h.Sum(nil), sum) {
+		return fmt.Errorf("checksum mismatch")
+	}
 
-// Write prunes deleted files then flushes all entries to .gitingo/index,
-// sorted by path for deterministic output.
-func (idx *Index) Write(repo *repository.Repository) error {
-	idx.pruneMissing(repo)
+	if v := binary.BigEndian.Uint32(body[4:]); v != indexVersion {
+		return fmt.Errorf("unsupported version %d", v)
+	}
+	hashLen := int(binary.BigEndian.Uint32(body[8:]))
+	count := int(binary.BigEndian.Uint32(body[12:]))
+	if hashLen != format.Size() {
+		return fmt.Errorf("hash size %d does not match the repository's %s", hashLen, format)
+	}
 
-	f, err := os.Create(filepath.Join(repo.GitDir, indexFile))
-	if err != nil {
-		return err
+	rest := body[16:]
+	for i := 0; i < count; i++ {
+		fixed := 8*4 + 4*2 + hashLen + 2
+		if len(rest) < fixed {
+			return fmt.Errorf("file truncated")
+		}
+		var e IndexEntry
+		e.Stat.CTime = int64(binary.BigEndian.Uint64(rest[0:]))
+		e.Stat.MTime = int64(binary.BigEndian.Uint64(rest[8:]))
+		e.Stat.Size = int64(binary.BigEndian.Uint64(rest[16:]))
+		e.Stat.Ino = binary.BigEndian.Uint64(rest[24:])
+		e.Stat.Mode = binary.BigEndian.Uint32(rest[32:])
+		e.Mode = strconv.FormatUint(uint64(binary.BigEndian.Uint32(rest[36:])), 8)
+		e.Hash = hex.EncodeToString(rest[40 : 40+hashLen])
+		n := int(binary.BigEndian.Uint16(rest[40+hashLen:]))
+		rest = rest[fixed:]
+		if len(rest) < n {
+			return fmt.Errorf("file truncated")
+		}
+		e.Path = string(rest[:n])
+		rest = rest[n:]
+		idx.Entries[e.Path] = e
 	}
-	defer f.Close()
+	if len(rest) != 0 {
+		return fmt.Errorf("%d trailing bytes", len(rest))
+	}
+	return nil
+}
 
+// encode serialises idx in the binary format.
+func (idx *Index) encode(format helper.ObjectFormat) ([]byte, error) {
 	paths := make([]string, 0, len(idx.Entries))
 	for p := range idx.Entries {
 		paths = append(paths, p)
 	}
 	sort.Strings(paths)
 
-	w := bufio.NewWriter(f)
+	var buf bytes.Buffer
+	buf.WriteString(indexMagic)
+	binary.Write(&buf, binary.BigEndian, [3]uint32{indexVersion, uint32(format.Size()), uint32(len(paths))})
+
 	for _, p := range paths {
 		e := idx.Entries[p]
-		fmt.Fprintf(w, "%s %s %s\n", e.Mode, e.Hash, e.Path)
+		mode, err := strconv.ParseUint(e.Mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad mode %q", p, e.Mode)
+		}
+		raw, err := hex.DecodeString(e.Hash)
+		if err != nil || len(raw) != format.Size() {
+			return nil, fmt.Errorf("%s: bad hash %q", p, e.Hash)
+		}
+		if len(p) > math.MaxUint16 {
+			return nil, fmt.Errorf("%s: path too long", p)
+		}
+
+		binary.Write(&buf, binary.BigEndian, [4]uint64{
+			uint64(e.Stat.CTime), uint64(e.Stat.MTime), uint64(e.Stat.Size), e.Stat.Ino,
+		})
+		binary.Write(&buf, binary.BigEndian, [2]uint32{e.Stat.Mode, uint32(mode)})
+		buf.Write(raw)
+		binary.Write(&buf, binary.BigEndian, uint16(len(p)))
+		buf.WriteString(p)
+	}
+
+	h := format.New()
+	h.Write(buf.Bytes())
+	buf.Write(h.Sum(nil))
+	return buf.Bytes(), nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Write
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Write prunes deleted files then flushes all entries to .gitingo/index,
+// sorted by path for deterministic output. It takes the index lock for the
+// duration; callers that already hold it use WriteLocked.
+func (idx *Index) Write(repo *repository.Repository) error {
+	lock, err := LockIndex(repo)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	return idx.WriteLocked(repo, lock)
+}
+
+// WriteLocked is Write for a caller holding lock, which it commits: the
+// new index is written into the lock file and renamed over the old one, so
+// a crash mid-write leaves the previous index intact.
+//
+// An entry whose file was modified no earlier than the index file itself is
+// "racily clean": a further edit within the same timestamp tick would leave
+// its stat data unchanged. Such entries have their stat data cleared, as
+// git does, so they are always rehashed until they are next staged. The
+// lock file's mtime becomes the index's, since rename keeps it.
+func (idx *Index) WriteLocked(repo *repository.Repository, lock *Lock) error {
+	idx.pruneMissing(repo)
+
+	for {
+		data, err := idx.encode(repo.Objects.Format())
+		if err != nil {
+			return err
+		}
+		if err := lock.replace(data); err != nil {
+			return err
+		}
+		info, err := lock.f.Stat()
+		if err != nil {
+			return err
+		}
+		idx.modTime = info.ModTime().UnixNano()
+		if !idx.smudgeRacy() {
+			return lock.commit()
+		}
+	}
+}
+
+// smudgeRacy clears the stat data of racily clean entries and reports
+// whether there were any.
+func (idx *Index) smudgeRacy() bool {
+	smudged := false
+	for p, e := range idx.Entries {
+		if e.Stat != (Stat{}) && e.Stat.MTime >= idx.modTime {
+			e.Stat = Stat{}
+			idx.Entries[p] = e
+			smudged = true
+		}
+	}
+	return smudged
+}
+
+// RecordStats stores the current stat data of every entry's file. It is
+// only correct right after the files were written from the entries' blobs,
+// as checkout does. A file whose mode on disk differs from its entry's is
+// left without stat data, so status still sees the difference.
+func (idx *Index) RecordStats(repo *repository.Repository) {
+	for p, e := range idx.Entries {
+		info, err := os.Lstat(filepath.Join(repo.WorkDir, filepath.FromSlash(p)))
+		if err != nil || helper.GitMode(info) != e.Mode {
+			continue
+		}
+		e.Stat = statOf(info)
+		idx.Entries[p] = e
 	}
-	return w.Flush()
 }
 
 // pruneMissing removes entries whose files no longer exist on disk.
@@ -120,7 +328,25 @@ func (idx *Index) pruneMissing(repo *repository.Repository) {
 // ─────────────────────────────────────────────────────────────────────────────
 
 // AddFiles stages the given paths (files or directories) relative to WorkDir.
-func (idx *Index) AddFiles(repo *repository.Repository, files []string) {
+// Paths that do not exist are skipped. Naming an ignored, untracked file
+// is an error, and nothing is staged.
+func (idx *Index) AddFiles(repo *repository.Repository, files []string) error {
+	ig := LoadIgnore(repo)
+	var ignored []string
+	for _, file := range files {
+		full := filepath.Join(repo.WorkDir, file)
+		info, err := os.Lstat(full)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if rel, ok := idx.relPath(repo, full); ok && idx.cached[rel].Hash == "" && ig.Match(rel, false) {
+			ignored = append(ignored, file)
+		}
+	}
+	if len(ignored) > 0 {
+		return fmt.Errorf("paths are ignored by %s or info/exclude: %s", ignoreFile, strings.Join(ignored, ", "))
+	}
+
 	for _, file := range files {
 		full := filepath.Join(repo.WorkDir, file)
 		info, err := os.Lstat(full)
@@ -128,57 +354,131 @@ func (idx *Index) AddFiles(repo *repository.Repository, files []string) {
 			continue
 		}
 		if info.IsDir() {
-			idx.AddFromPath(repo, full, true)
+			err = idx.AddFromPath(repo, full, true)
 		} else {
-			idx.addFile(repo, full, true)
+			err = idx.addFile(repo, full, true)
+		}
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
 // AddFromPath walks start recursively, staging every file found.
 // toWrite controls whether blobs are written to the object store.
-func (idx *Index) AddFromPath(repo *repository.Repository, start string, toWrite bool) {
-	filepath.WalkDir(start, func(curr string, d os.DirEntry, err error) error {
+// Ignored paths are skipped unless they are already tracked.
+func (idx *Index) AddFromPath(repo *repository.Repository, start string, toWrite bool) error {
+	ig := LoadIgnore(repo)
+	return filepath.WalkDir(start, func(curr string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
-		if d.IsDir() {
-			if d.Name() == repo.GitFolder || d.Name() == ".git" || d.Name() == repo.GitFolder {
+		if d.IsDir() && (d.Name() == repo.GitFolder || d.Name() == ".git") {
+			return filepath.SkipDir
+		}
+
+		rel, ok := idx.relPath(repo, curr)
+		if ok && rel != "." && ig.Match(rel, d.IsDir()) {
+			switch {
+			case d.IsDir() && !idx.tracksUnder(rel):
 				return filepath.SkipDir
+			case !d.IsDir() && idx.cached[rel].Hash == "":
+				return nil
 			}
+		}
+		if d.IsDir() {
 			return nil
 		}
-		idx.addFile(repo, curr, toWrite)
-		return nil
+		return idx.addFile(repo, curr, toWrite)
 	})
 }
 
+// relPath returns full's slash-separated path relative to the work tree.
+func (idx *Index) relPath(repo *repository.Repository, full string) (string, bool) {
+	rel, err := filepath.Rel(repo.WorkDir, full)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// tracksUnder reports whether any staged entry lies inside dir, in which
+// case an ignored dir must still be walked to keep those entries current.
+func (idx *Index) tracksUnder(dir string) bool {
+	for p := range idx.cached {
+		if strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // addFile hashes a single file and updates the in-memory index entry.
-// When toWrite is true the blob is persisted to the object store.
-func (idx *Index) addFile(repo *repository.Repository, fullPath string, toWrite bool) {
-	mode, content, ok := helper.ReadFileContent(fullPath)
+// When toWrite is true the blob — or, past repo.ChunkThreshold, its chunks
+// and chunk list — is persisted to the object store.
+// The file is streamed, so memory use does not grow with file size.
+//
+// A file that vanishes before it can be opened is skipped; any failure
+// after that — including failing to store the blob — is returned.
+func (idx *Index) addFile(repo *repository.Repository, fullPath string, toWrite bool) error {
+	relPath, ok := idx.relPath(repo, fullPath)
 	if !ok {
-		return
+		return fmt.Errorf("%s: outside the work tree", fullPath)
 	}
 
-	var hash string
-	if toWrite {
-		hash = helper.WriteObject(repo.GitDir, "blob", content)
-	} else {
-		_, hash = helper.PrepareObject("blob", content)
+	// Stat before reading: an edit made while the file is hashed then
+	// changes the mtime, and the next look rehashes it.
+	info, err := os.Lstat(fullPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	st := statOf(info)
+	if e, ok := idx.cached[relPath]; ok && idx.unchanged(e, st) {
+		// A staged entry's blob is already stored, so even when toWrite
+		// is set there is nothing to write.
+		idx.Entries[relPath] = e
+		return nil
 	}
 
-	relPath, err := filepath.Rel(repo.WorkDir, fullPath)
+	mode, size, r, err := helper.OpenFileContent(fullPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		return
+		return err
 	}
-	idx.updateEntry(filepath.ToSlash(relPath), mode, hash)
-}
+	defer r.Close()
 
-// updateEntry writes an entry only when the hash or mode has actually changed.
-func (idx *Index) updateEntry(path, mode, hash string) {
-	if old, ok := idx.Entries[path]; ok && old.Hash == hash && old.Mode == mode {
-		return
+	var hash string
+	switch {
+	case repo.ChunkThreshold > 0 && size >= repo.ChunkThreshold:
+		// Large files become chunk lists so that small edits only store
+		// the chunks they touch.
+		var store helper.ObjectStore
+		if toWrite {
+			store = repo.Objects
+		}
+		hash, err = helper.WriteChunked(store, repo.Objects.Format(), size, r)
+	case toWrite:
+		hash, err = repo.Objects.WriteStream("blob", size, r)
+	default:
+		hash, err = helper.HashStream(repo.Objects.Format(), "blob", size, r)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fullPath, err)
 	}
-	idx.Entries[path] = IndexEntry{Mode: mode, Hash: hash, Path: path}
+
+	idx.Entries[relPath] = IndexEntry{Mode: mode, Hash: hash, Path: relPath, Stat: st}
+	return nil
+}
+
+// unchanged reports whether a file with stat data st can be assumed to
+// still match e without rehashing. Entries with no stat data, or modified
+// no earlier than the index was written (racily clean), never qualify.
+func (idx *Index) unchanged(e IndexEntry, st Stat) bool {
+	return e.Stat != (Stat{}) && e.Stat == st && e.Stat.MTime < idx.modTime
 }
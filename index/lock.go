@@ -0,0 +1,94 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kasodeep/gitingo/helper"
+	"github.com/kasodeep/gitingo/repository"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Index lock
+// ─────────────────────────────────────────────────────────────────────────────
+//
+// Every change to the index goes through .gitingo/index.lock: it is created
+// with O_EXCL, so only one process can hold it, the new index is written
+// into it, and it is renamed over the index to commit. A second process
+// fails fast instead of overwriting the first one's work.
+//
+// Commands that read, modify and write the index take the lock before
+// reading, so no other process can change the index in between.
+
+const lockSuffix = ".lock"
+
+// Lock is a held index.lock. Release it on every path; after
+// Index.WriteLocked has committed it, Release does nothing.
+type Lock struct {
+	path   string
+	target string
+	f      *os.File
+}
+
+// LockIndex acquires the index lock, failing with an error that names the
+// lock file if another process holds it.
+func LockIndex(repo *repository.Repository) (*Lock, error) {
+	target := filepath.Join(repo.GitDir, indexFile)
+	path := target + lockSuffix
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("unable to create '%s': file exists.\n"+
+			"Another gitingo process seems to be running in this repository.\n"+
+			"If none is, a previous one crashed: remove the file and try again", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create '%s': %w", path, err)
+	}
+	return &Lock{path: path, target: target, f: f}, nil
+}
+
+// Release gives the lock up without changing the index.
+func (l *Lock) Release() {
+	if l.f == nil {
+		return
+	}
+	l.f.Close()
+	os.Remove(l.path)
+	l.f = nil
+}
+
+// replace overwrites the lock file's content with data.
+func (l *Lock) replace(data []byte) error {
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := l.f.WriteAt(data, 0)
+	return err
+}
+
+// commit makes the lock file's content durable and renames it over the
+// index, releasing the lock.
+func (l *Lock) commit() error {
+	if l.f == nil {
+		return fmt.Errorf("'%s' is not held", l.path)
+	}
+	if err := l.f.Sync(); err != nil {
+		l.Release()
+		return err
+	}
+	if err := l.f.Close(); err != nil {
+		l.f = nil
+		os.Remove(l.path)
+		return err
+	}
+	l.f = nil
+	if err := os.Rename(l.path, l.target); err != nil {
+		os.Remove(l.path)
+		return err
+	}
+	return helper.SyncDir(filepath.Dir(l.target))
+}
@@ -0,0 +1,22 @@
+//go:build linux
+
+package index
+
+import (
+	"os"
+	"syscall"
+)
+
+// statOf extracts the fields the index caches from a file's stat data.
+func statOf(info os.FileInfo) Stat {
+	st := Stat{
+		MTime: info.ModTime().UnixNano(),
+		Size:  info.Size(),
+		Mode:  uint32(info.Mode()),
+	}
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		st.CTime = sys.Ctim.Nano()
+		st.Ino = sys.Ino
+	}
+	return st
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package index
+
+import "os"
+
+// statOf extracts the fields the index caches from a file's stat data.
+// Only the portable fields are available here; ctime and inode stay zero
+// and so never cause a mismatch on their own.
+func statOf(info os.FileInfo) Stat {
+	return Stat{
+		MTime: info.ModTime().UnixNano(),
+		Size:  info.Size(),
+		Mode:  uint32(info.Mode()),
+	}
+}
@@ -3,9 +3,12 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/kasodeep/gitingo/helper"
 )
 
 var ErrBranchNotExists = errors.New("branch does not exist")
@@ -27,7 +30,7 @@ func (r *Repository) LoadCurrentBranch() error {
 		return nil
 	}
 
-	r.CurrBranch = filepath.Base(strings.TrimPrefix(content, "ref: "))
+	r.CurrBranch = strings.TrimPrefix(strings.TrimPrefix(content, "ref: "), refsFolder+"/"+headsDir+"/")
 	return nil
 }
 
@@ -48,6 +51,7 @@ func (repo *Repository) ReadHead() (string, error) {
 }
 
 // WriteHead writes commitHash to the current branch ref (or HEAD if detached).
+// The ref is replaced atomically, so a crash cannot leave it empty.
 func (repo *Repository) WriteHead(commitHash []byte) error {
 	var path string
 	if repo.IsDetached {
@@ -55,7 +59,7 @@ func (repo *Repository) WriteHead(commitHash []byte) error {
 	} else {
 		path = filepath.Join(repo.GitDir, refsFolder, headsDir, repo.CurrBranch)
 	}
-	return os.WriteFile(path, commitHash, 0644)
+	return helper.WriteFileAtomic(path, commitHash, 0644)
 }
 
 // UpdateHeadWithLog writes the new commit hash and appends a reflog entry.
@@ -65,7 +69,9 @@ func (repo *Repository) UpdateHeadWithLog(newHash, msg string) error {
 		return err
 	}
 	if oldHash != "" {
-		repo.WriteRefLog(oldHash, newHash, msg)
+		if err := repo.WriteRefLog(oldHash, newHash, msg); err != nil {
+			return err
+		}
 	}
 	return repo.WriteHead([]byte(newHash))
 }
@@ -78,7 +84,7 @@ func (r *Repository) AttachHead(branch string) error {
 	}
 
 	content := fmt.Sprintf("ref: %s/%s/%s\n", refsFolder, headsDir, branch)
-	if err := os.WriteFile(filepath.Join(r.GitDir, "HEAD"), []byte(content), 0644); err != nil {
+	if err := helper.WriteFileAtomic(filepath.Join(r.GitDir, "HEAD"), []byte(content), 0644); err != nil {
 		return err
 	}
 
@@ -89,7 +95,7 @@ func (r *Repository) AttachHead(branch string) error {
 
 // DeattachHead makes HEAD point directly to a commit hash (detached state).
 func (r *Repository) DeattachHead(hash string) error {
-	if err := os.WriteFile(filepath.Join(r.GitDir, "HEAD"), []byte(hash), 0644); err != nil {
+	if err := helper.WriteFileAtomic(filepath.Join(r.GitDir, "HEAD"), []byte(hash), 0644); err != nil {
 		return err
 	}
 
@@ -109,31 +115,63 @@ func (r *Repository) CreateBranch(name string) error {
 	if err != nil || hash == "" {
 		return fmt.Errorf("no commit yet")
 	}
-	return os.WriteFile(
+	return helper.WriteFileAtomic(
 		filepath.Join(r.GitDir, refsFolder, headsDir, name),
 		[]byte(hash),
 		0644,
 	)
 }
 
+// ReadBranch returns the commit hash refs/heads/<name> points to.
+// An unborn branch returns an empty hash.
+func (r *Repository) ReadBranch(name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, refsFolder, headsDir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// WriteBranch points refs/heads/<name> at hash, creating the branch if
+// needed. Names may contain slashes, e.g. "feature/login".
+func (r *Repository) WriteBranch(name, hash string) error {
+	path := filepath.Join(r.GitDir, refsFolder, headsDir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return helper.WriteFileAtomic(path, []byte(hash), 0644)
+}
+
+// DeleteBranch removes refs/heads/<name>.
+func (r *Repository) DeleteBranch(name string) error {
+	return os.Remove(filepath.Join(r.GitDir, refsFolder, headsDir, name))
+}
+
 // IsBranchExists reports whether refs/heads/<name> exists on disk.
 func (r *Repository) IsBranchExists(name string) bool {
 	_, err := os.Stat(filepath.Join(r.GitDir, refsFolder, headsDir, name))
 	return err == nil
 }
 
-// ListBranches returns the names of all local branches.
+// ListBranches returns the names of all local branches, including nested
+// ones such as "feature/login".
 func (r *Repository) ListBranches() ([]string, error) {
-	entries, err := os.ReadDir(filepath.Join(r.GitDir, refsFolder, headsDir))
+	heads := filepath.Join(r.GitDir, refsFolder, headsDir)
+	var branches []string
+	err := filepath.WalkDir(heads, func(path string, d fs.DirEntry, err error) error {
+		// Dot-files are in-flight temp files from an atomic ref update.
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		name, err := filepath.Rel(heads, path)
+		if err != nil {
+			return err
+		}
+		branches = append(branches, filepath.ToSlash(name))
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	branches := make([]string, 0, len(entries))
-	for _, e := range entries {
-		if !e.IsDir() {
-			branches = append(branches, e.Name())
-		}
-	}
 	return branches, nil
 }
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Parsed-object cache
+// ─────────────────────────────────────────────────────────────────────────────
+
+// DefaultCacheSize is how many parsed objects a Repository keeps by default.
+const DefaultCacheSize = 4096
+
+// Process-wide hit/miss totals across every cache, reported by --trace.
+var cacheHits, cacheMisses atomic.Int64
+
+// ObjectCache is a bounded LRU of parsed objects — tree entries, commits —
+// keyed by kind and hash. Objects are immutable, so entries never go
+// stale; the least recently used one is evicted once the cache is full.
+//
+// A nil *ObjectCache is valid and caches nothing.
+type ObjectCache struct {
+	mu           sync.Mutex
+	max          int
+	order        *list.List // front is most recently used
+	items        map[string]*list.Element
+	hits, misses int64
+}
+
+// cacheItem is one list element's payload.
+type cacheItem struct {
+	key   string
+	value any
+}
+
+// NewObjectCache returns an empty cache holding at most max objects.
+func NewObjectCache(max int) *ObjectCache {
+	return &ObjectCache{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the object cached under kind and hash, if any.
+func (c *ObjectCache) Get(kind, hash string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[kind+":"+hash]
+	if !ok {
+		c.misses++
+		cacheMisses.Add(1)
+		return nil, false
+	}
+	c.hits++
+	cacheHits.Add(1)
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheItem).value, true
+}
+
+// Add caches value under kind and hash, evicting the least recently used
+// object if the cache is full. Callers must not mutate value afterwards.
+func (c *ObjectCache) Add(kind, hash string, value any) {
+	if c == nil || c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := kind + ":" + hash
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheItem).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheItem{key, value})
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
+
+// Stats returns this cache's hit and miss counts and its current size.
+func (c *ObjectCache) Stats() (hits, misses int64, size int) {
+	if c == nil {
+		return 0, 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.order.Len()
+}
+
+// CacheStats returns hit and miss totals across every Repository's cache
+// in this process.
+func CacheStats() (hits, misses int64) {
+	return cacheHits.Load(), cacheMisses.Load()
+}
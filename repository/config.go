@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/kasodeep/gitingo/helper"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -21,27 +25,73 @@ func WriteConfig(gitDir, name, email string) error {
 	if email != "" {
 		curr.Email = email
 	}
+	return writeConfig(gitDir, curr)
+}
+
+// SetObjectFormat records the repository's object format in .gitingo/config.
+// Called once by Create; the format cannot change after objects exist.
+func SetObjectFormat(gitDir string, format helper.ObjectFormat) error {
+	curr := ReadConfig(gitDir)
+	curr.ObjectFormat = string(format)
+	return writeConfig(gitDir, curr)
+}
+
+// SetChunkThreshold records the size, e.g. "8m", from which files are
+// stored as chunk lists rather than single blobs. "0" turns chunking off.
+//
+// Changing the threshold changes the ids files above it hash to, so such
+// files show as modified until they are staged again.
+//
+// Chunk lists have no git equivalent: trees and commits holding them get
+// ids git would never compute for the same content. A sha1 repository
+// exists to keep git-compatible ids, so chunking is refused there.
+func SetChunkThreshold(gitDir, value string) error {
+	threshold, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	curr := ReadConfig(gitDir)
+	if threshold > 0 && curr.ObjectFormat == string(helper.SHA1) {
+		return fmt.Errorf("core.chunkthreshold: chunking would make sha1 object ids incompatible with git")
+	}
+	curr.ChunkThreshold = value
+	return writeConfig(gitDir, curr)
+}
 
+// writeConfig serialises cfg, omitting empty values.
+func writeConfig(gitDir string, cfg Config) error {
 	var b strings.Builder
+	if cfg.ObjectFormat != "" {
+		b.WriteString("[extensions]\n")
+		b.WriteString("\tobjectformat = " + cfg.ObjectFormat + "\n")
+	}
+	if cfg.ChunkThreshold != "" {
+		b.WriteString("[core]\n")
+		b.WriteString("\tchunkthreshold = " + cfg.ChunkThreshold + "\n")
+	}
 	b.WriteString("[user]\n")
-	if curr.Name != "" {
-		b.WriteString("\tname = " + curr.Name + "\n")
+	if cfg.Name != "" {
+		b.WriteString("\tname = " + cfg.Name + "\n")
 	}
-	if curr.Email != "" {
-		b.WriteString("\temail = " + curr.Email + "\n")
+	if cfg.Email != "" {
+		b.WriteString("\temail = " + cfg.Email + "\n")
 	}
 
-	return os.WriteFile(filepath.Join(gitDir, configFile), []byte(b.String()), 0644)
+	return helper.WriteFileAtomic(filepath.Join(gitDir, configFile), []byte(b.String()), 0644)
 }
 
-// Config holds the user identity stored in .gitingo/config.
+// Config holds the settings stored in .gitingo/config: the user identity,
+// the object format and the chunking threshold. An empty ObjectFormat means
+// the default (sha256); an empty ChunkThreshold means no chunking.
 type Config struct {
-	Name  string
-	Email string
+	Name           string
+	Email          string
+	ObjectFormat   string
+	ChunkThreshold string
 }
 
-// ReadConfig parses .gitingo/config and returns the current user identity.
-// Missing or unreadable config returns a zero Config (both fields empty).
+// ReadConfig parses .gitingo/config and returns the current settings.
+// Missing or unreadable config returns a zero Config (all fields empty).
 func ReadConfig(gitDir string) Config {
 	var cfg Config
 
@@ -58,6 +108,37 @@ func ReadConfig(gitDir string) Config {
 		if v, ok := strings.CutPrefix(line, "email ="); ok {
 			cfg.Email = strings.TrimSpace(v)
 		}
+		if v, ok := strings.CutPrefix(line, "objectformat ="); ok {
+			cfg.ObjectFormat = strings.TrimSpace(v)
+		}
+		if v, ok := strings.CutPrefix(line, "chunkthreshold ="); ok {
+			cfg.ChunkThreshold = strings.TrimSpace(v)
+		}
 	}
 	return cfg
 }
+
+// parseSize parses a byte count with an optional k, m or g suffix
+// (binary units). An empty string is zero.
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	num, unit := s, int64(1)
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "k":
+		unit = 1 << 10
+	case "m":
+		unit = 1 << 20
+	case "g":
+		unit = 1 << 30
+	}
+	if unit != 1 {
+		num = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
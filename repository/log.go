@@ -2,8 +2,10 @@ package repository
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -32,3 +34,31 @@ func (repo *Repository) WriteRefLog(oldHash, newHash, msg string) error {
 	_, err = fmt.Fprintf(f, "%s %s %s\n", oldHash, newHash, msg)
 	return err
 }
+
+// RefLogHashes returns every commit hash recorded in any reflog, on either
+// side of a transition, so that commits a ref has moved away from still
+// count as reachable.
+func (repo *Repository) RefLogHashes() ([]string, error) {
+	var hashes []string
+	root := filepath.Join(repo.GitDir, "logs")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			for i := 0; i < len(fields) && i < 2; i++ {
+				hashes = append(hashes, fields[i])
+			}
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return hashes, err
+}
@@ -36,12 +36,21 @@ var (
 
 // Repository is the in-memory handle for a gitingo repo.
 // Every command receives one via GetRepository.
+//
+// Objects defaults to the loose-file store under GitDir; embedders and
+// tests may swap in another helper.ObjectStore, e.g. helper.NewMemoryStore(helper.SHA256).
 type Repository struct {
-	WorkDir    string // absolute path to the working directory
-	GitFolder  string // name of the git folder (always ".gitingo")
-	GitDir     string // absolute path to .gitingo/
-	CurrBranch string // current branch name; empty when detached
-	IsDetached bool   // true when HEAD points directly to a commit hash
+	WorkDir    string             // absolute path to the working directory
+	GitFolder  string             // name of the git folder (always ".gitingo")
+	GitDir     string             // absolute path to .gitingo/
+	CurrBranch string             // current branch name; empty when detached
+	IsDetached bool               // true when HEAD points directly to a commit hash
+	Objects    helper.ObjectStore // where blobs, trees and commits are kept
+	Cache      *ObjectCache       // parsed trees and commits; nil disables caching
+
+	// ChunkThreshold is the file size from which content is stored as a
+	// chunk list instead of a single blob; 0 disables chunking.
+	ChunkThreshold int64
 }
 
 // GetRepository loads an existing repo rooted at base.
@@ -52,10 +61,24 @@ func GetRepository(base string) (*Repository, error) {
 		return nil, fmt.Errorf("not a gitingo repository (or any of the parent directories)")
 	}
 
+	cfg := ReadConfig(gitDir)
+	format, err := helper.ParseObjectFormat(cfg.ObjectFormat)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := parseSize(cfg.ChunkThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("core.chunkthreshold: %w", err)
+	}
+
 	repo := &Repository{
 		WorkDir:   base,
 		GitDir:    gitDir,
 		GitFolder: gitFolder,
+		Objects:   helper.NewLooseStore(gitDir, format),
+		Cache:     NewObjectCache(DefaultCacheSize),
+
+		ChunkThreshold: threshold,
 	}
 
 	if err := repo.LoadCurrentBranch(); err != nil {
@@ -64,15 +87,18 @@ func GetRepository(base string) (*Repository, error) {
 	return repo, nil
 }
 
-// NewRepository returns an uninitialised Repository value for base.
-// Call Create() to write it to disk.
-func NewRepository(base string) *Repository {
+// NewRepository returns an uninitialised Repository value for base that
+// will hash objects with format. Call Create() to write it to disk.
+func NewRepository(base string, format helper.ObjectFormat) *Repository {
+	gitDir := filepath.Join(base, gitFolder)
 	return &Repository{
 		WorkDir:    base,
 		GitFolder:  gitFolder,
-		GitDir:     filepath.Join(base, gitFolder),
+		GitDir:     gitDir,
 		CurrBranch: initBranch,
 		IsDetached: true,
+		Objects:    helper.NewLooseStore(gitDir, format),
+		Cache:      NewObjectCache(DefaultCacheSize),
 	}
 }
 
@@ -91,6 +117,7 @@ func (r *Repository) Create() error {
 		func() error { return os.Mkdir(r.GitDir, 0755) },
 		r.createDirs,
 		r.createFiles,
+		func() error { return SetObjectFormat(r.GitDir, r.Objects.Format()) },
 		r.initRefs,
 		func() error { return r.AttachHead(initBranch) },
 	} {
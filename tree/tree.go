@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -76,46 +77,146 @@ func TreeToIndex(idx *index.Index, node *TreeNode, prefix string) {
 
 // WriteTree serialises root and all subtrees into the object store.
 // Returns the hash of the root tree object.
-func WriteTree(gitDir string, root *TreeNode) string {
+func WriteTree(repo *repository.Repository, root *TreeNode) (string, error) {
 	var buf bytes.Buffer
-	writeNode(gitDir, root, &buf)
-	return helper.WriteObject(gitDir, "tree", buf.Bytes())
+	if err := writeNode(repo, root, &buf); err != nil {
+		return "", err
+	}
+	return repo.Objects.Write("tree", buf.Bytes())
 }
 
-// writeNode serialises one tree node in git's binary tree format:
-//
-//	"<mode> <name>\0<20-byte-hash>" per entry, dirs before files, both sorted.
-func writeNode(gitDir string, node *TreeNode, w io.Writer) {
+// writeNode writes every subtree of node, then serialises node itself
+// with EncodeEntries.
+func writeNode(repo *repository.Repository, node *TreeNode, w io.Writer) error {
+	entries := make([]Entry, 0, len(node.Dirs)+len(node.Files))
 	for _, name := range sortedKeys(node.Dirs) {
-		subHash := WriteTree(gitDir, node.Dirs[name])
-		hashBytes, _ := hex.DecodeString(subHash)
-		io.WriteString(w, "40000 "+name)
-		w.Write([]byte{0})
-		w.Write(hashBytes)
+		subHash, err := WriteTree(repo, node.Dirs[name])
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{Mode: "40000", Name: name, Hash: subHash})
 	}
-
 	for _, name := range sortedKeys(node.Files) {
-		entry := node.Files[name]
-		hashBytes, _ := hex.DecodeString(entry.Hash)
-		io.WriteString(w, entry.Mode+" "+name)
-		w.Write([]byte{0})
-		w.Write(hashBytes)
+		e := node.Files[name]
+		entries = append(entries, Entry{Mode: e.Mode, Name: name, Hash: e.Hash})
+	}
+
+	content, err := EncodeEntries(repo.Objects.Format(), entries)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// EncodeEntries serialises entries in git's binary tree format:
+//
+//	"<mode> <name>\0<raw-hash>" per entry
+//
+// The raw hash is as wide as the object format (32 bytes for sha256, 20
+// for sha1). SHA-256 trees list dirs before files, both sorted — the
+// historical order, kept so existing tree ids stay stable. SHA-1 trees use
+// git's canonical order (dirs compare as "name/") so that git can read
+// them. entries is sorted in place.
+func EncodeEntries(format helper.ObjectFormat, entries []Entry) ([]byte, error) {
+	if format == helper.SHA1 {
+		sort.Slice(entries, func(i, j int) bool { return gitSortKey(entries[i]) < gitSortKey(entries[j]) })
+	} else {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].IsDir() != entries[j].IsDir() {
+				return entries[i].IsDir()
+			}
+			return entries[i].Name < entries[j].Name
+		})
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		hashBytes, err := hex.DecodeString(e.Hash)
+		if err != nil || len(hashBytes) != format.Size() {
+			return nil, fmt.Errorf("tree entry %s: invalid %s hash %q", e.Name, format, e.Hash)
+		}
+		buf.WriteString(e.Mode + " " + e.Name)
+		buf.WriteByte(0)
+		buf.Write(hashBytes)
 	}
+	return buf.Bytes(), nil
+}
+
+// gitSortKey is the name git orders tree entries by: directories sort as
+// if their name ended in "/".
+func gitSortKey(e Entry) string {
+	if e.IsDir() {
+		return e.Name + "/"
+	}
+	return e.Name
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Deserialisation
 // ─────────────────────────────────────────────────────────────────────────────
 
+// Entry is one raw record of a tree object: "<mode> <name>\0<hash>".
+type Entry struct {
+	Mode string
+	Name string
+	Hash string
+}
+
+// IsDir reports whether the entry points at a subtree.
+func (e Entry) IsDir() bool { return e.Mode == "40000" }
+
 // ParseTree reads a tree object by hash and reconstructs its TreeNode.
 // Subtrees are parsed recursively; base accumulates the path prefix.
 func ParseTree(repo *repository.Repository, hash, base string) (*TreeNode, error) {
-	content, ok := helper.ReadObject(repo.GitDir, hash)
-	if !ok {
-		return nil, fmt.Errorf("tree object not found: %s", hash)
+	entries, err := ReadEntries(repo, hash)
+	if err != nil {
+		return nil, err
 	}
 
 	root := NewTree()
+	for _, e := range entries {
+		if e.IsDir() {
+			sub, err := ParseTree(repo, e.Hash, filepath.Join(base, e.Name))
+			if err != nil {
+				return nil, err
+			}
+			root.Dirs[e.Name] = sub
+		} else {
+			root.Files[e.Name] = index.IndexEntry{
+				Mode: e.Mode,
+				Hash: e.Hash,
+				Path: filepath.Join(base, e.Name),
+			}
+		}
+	}
+	return root, nil
+}
+
+// ReadEntries reads a tree object by hash and decodes its direct entries
+// without descending into subtrees. Decoded trees are kept in repo.Cache;
+// callers get their own copy of the slice.
+func ReadEntries(repo *repository.Repository, hash string) ([]Entry, error) {
+	if v, ok := repo.Cache.Get("tree", hash); ok {
+		return slices.Clone(v.([]Entry)), nil
+	}
+
+	_, content, err := repo.Objects.Read(hash)
+	if err != nil {
+		return nil, fmt.Errorf("tree object not found: %s", hash)
+	}
+	entries, err := DecodeEntries(content, repo.Objects.Format().Size())
+	if err != nil {
+		return nil, err
+	}
+	repo.Cache.Add("tree", hash, entries)
+	return slices.Clone(entries), nil
+}
+
+// DecodeEntries parses the binary body of a tree object whose entry hashes
+// are hashSize bytes wide.
+func DecodeEntries(content []byte, hashSize int) ([]Entry, error) {
+	var entries []Entry
 	for i := 0; i < len(content); {
 		// mode
 		space := bytes.IndexByte(content[i:], ' ')
@@ -133,28 +234,16 @@ func ParseTree(repo *repository.Repository, hash, base string) (*TreeNode, error
 		name := string(content[i : i+nul])
 		i += nul + 1
 
-		// 32-byte hash (SHA-256)
-		if i+32 > len(content) {
+		// raw hash: 32 bytes for sha256, 20 for sha1
+		if i+hashSize > len(content) {
 			return nil, fmt.Errorf("truncated hash at offset %d", i)
 		}
-		entryHash := hex.EncodeToString(content[i : i+32])
-		i += 32
+		entryHash := hex.EncodeToString(content[i : i+hashSize])
+		i += hashSize
 
-		if mode == "40000" {
-			sub, err := ParseTree(repo, entryHash, filepath.Join(base, name))
-			if err != nil {
-				return nil, err
-			}
-			root.Dirs[name] = sub
-		} else {
-			root.Files[name] = index.IndexEntry{
-				Mode: mode,
-				Hash: entryHash,
-				Path: filepath.Join(base, name),
-			}
-		}
+		entries = append(entries, Entry{Mode: mode, Name: name, Hash: entryHash})
 	}
-	return root, nil
+	return entries, nil
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -170,21 +259,67 @@ func WriteReverse(repo *repository.Repository, node *TreeNode, base string) erro
 		}
 	}
 	for name, entry := range node.Files {
-		content, ok := helper.ReadObject(repo.GitDir, entry.Hash)
-		if !ok {
-			return fmt.Errorf("blob not found for %s", name)
-		}
 		path := filepath.Join(repo.WorkDir, base, name)
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return err
-		}
-		if err := os.WriteFile(path, content, 0644); err != nil {
-			return err
+		if err := writeBlob(repo, entry.Hash, entry.Mode, path); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Join(base, name), err)
 		}
 	}
 	return nil
 }
 
+// WriteFile writes the blob hash to relPath, a slash-separated path in the
+// working directory, with the given entry mode, creating parent directories
+// as needed. Used by restore.
+func WriteFile(repo *repository.Repository, relPath, mode, hash string) error {
+	return writeBlob(repo, hash, mode, filepath.Join(repo.WorkDir, filepath.FromSlash(relPath)))
+}
+
+// writeBlob streams a blob, or the chunks of a chunk list, from the object
+// store into path, and sets or clears the file's exec bit to match mode.
+func writeBlob(repo *repository.Repository, hash, mode, path string) error {
+	_, r, err := helper.OpenBlob(repo.Objects, hash)
+	if err != nil {
+		return fmt.Errorf("blob not found: %s", hash)
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := setExecutable(f, mode == "100755"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// setExecutable adds or removes f's exec bits. OpenFile's permissions
+// only apply when it creates the file, so an overwritten file keeps
+// whatever mode it had.
+func setExecutable(f *os.File, exec bool) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	perm := info.Mode().Perm()
+	want := perm &^ 0111
+	if exec {
+		want |= (perm & 0444) >> 2
+	}
+	if want == perm {
+		return nil
+	}
+	return f.Chmod(want)
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // Helpers
 // ─────────────────────────────────────────────────────────────────────────────